@@ -0,0 +1,126 @@
+package odbc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/piquette/finance-go"
+)
+
+// AssetClass describes how one quote type is fetched, converted and
+// stored. Name and Aliases are matched case-insensitively against the
+// quote type reported by the provider.
+type AssetClass struct {
+	Name      string
+	Title     string
+	QuoteType finance.QuoteType
+	TableName string
+	Aliases   []string
+
+	// Fetch obtains the API representation of symbol. If nil, the
+	// provider's GetAsset is asked for QuoteType.
+	Fetch func(ctx context.Context, p Provider, symbol string) (interface{}, error)
+
+	// Convert turns the API representation into the stored struct.
+	Convert func(interface{}) (interface{}, bool)
+
+	// NewAPI returns an empty API representation to decode into.
+	NewAPI func() interface{}
+
+	// Schema is a zero value of the stored struct.
+	Schema interface{}
+}
+
+func (c AssetClass) Get(ctx context.Context, p Provider, symbol string) (interface{}, error) {
+	if c.Fetch != nil {
+		return c.Fetch(ctx, p, symbol)
+	}
+	return p.GetAsset(ctx, c.QuoteType, symbol)
+}
+
+var (
+	assetClassesMu     sync.RWMutex
+	assetClasses       = []AssetClass{}
+	assetClassesByName = map[string]int{}
+)
+
+func init() {
+	for _, c := range []AssetClass{
+		cryptoAssetClass,
+		equityAssetClass,
+		etfAssetClass,
+		forexAssetClass,
+		futureAssetClass,
+		indexAssetClass,
+		mutualFundAssetClass,
+		optionAssetClass,
+	} {
+		if err := RegisterAssetClass(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterAssetClass makes c available to LookupAssetClass and
+// AssetClasses. It fails if the name or one of the aliases is already
+// taken or if c cannot be converted.
+func RegisterAssetClass(c AssetClass) error {
+	if c.Name == "" {
+		return fmt.Errorf("asset class has no name")
+	}
+	if c.Convert == nil {
+		return fmt.Errorf("asset class %s has no converter", c.Name)
+	}
+	if c.Fetch == nil && c.QuoteType == "" {
+		return fmt.Errorf("asset class %s has neither a fetcher nor a quote type", c.Name)
+	}
+	if c.TableName == "" {
+		c.TableName = strings.ToLower(c.Name)
+	}
+	if c.Title == "" {
+		c.Title = c.Name
+	}
+
+	assetClassesMu.Lock()
+	defer assetClassesMu.Unlock()
+
+	keys := append([]string{c.Name}, c.Aliases...)
+	for _, k := range keys {
+		if _, ok := assetClassesByName[strings.ToLower(k)]; ok {
+			return fmt.Errorf("asset class %s is already registered", k)
+		}
+	}
+
+	assetClasses = append(assetClasses, c)
+	for _, k := range keys {
+		assetClassesByName[strings.ToLower(k)] = len(assetClasses) - 1
+	}
+	return nil
+}
+
+// LookupAssetClass returns the asset class registered under name or
+// one of its aliases.
+func LookupAssetClass(name string) (c AssetClass, ok bool) {
+	assetClassesMu.RLock()
+	defer assetClassesMu.RUnlock()
+
+	i, ok := assetClassesByName[strings.ToLower(name)]
+	if !ok {
+		return
+	}
+	c = assetClasses[i]
+	return
+}
+
+// AssetClasses returns all registered asset classes in registration
+// order.
+func AssetClasses() []AssetClass {
+	assetClassesMu.RLock()
+	defer assetClassesMu.RUnlock()
+
+	cs := make([]AssetClass, len(assetClasses))
+	copy(cs, assetClasses)
+	return cs
+}
@@ -0,0 +1,80 @@
+package odbc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/piquette/finance-go"
+)
+
+// unregisterAssetClass removes the asset class registered under name
+// and its aliases, so tests leave the registry as they found it.
+func unregisterAssetClass(name string) {
+	assetClassesMu.Lock()
+	defer assetClassesMu.Unlock()
+
+	cs := assetClasses
+	assetClasses, assetClassesByName = []AssetClass{}, map[string]int{}
+	for _, c := range cs {
+		if strings.EqualFold(c.Name, name) {
+			continue
+		}
+		assetClasses = append(assetClasses, c)
+		for _, k := range append([]string{c.Name}, c.Aliases...) {
+			assetClassesByName[strings.ToLower(k)] = len(assetClasses) - 1
+		}
+	}
+}
+
+type certificateQuote struct {
+	Symbol string `db:"symbol" json:"symbol"`
+}
+
+func TestAssetClassRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	c, ok := LookupAssetClass("ECNQUOTE")
+	if !ok || c.Name != "equity" || c.TableName != "equity" {
+		t.Fatalf("ecnquote resolves to %+v", c)
+	}
+	a, err := c.Get(ctx, fixtureProvider{}, "FIX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := c.Convert(a); !ok || e.(Equity).LongName != "Fixture Inc." {
+		t.Errorf("converted %v to %v", a, e)
+	}
+
+	certificate := AssetClass{
+		Name:    "Certificate",
+		Fetch:   func(ctx context.Context, p Provider, symbol string) (interface{}, error) { return symbol, nil },
+		Convert: func(a interface{}) (interface{}, bool) { return certificateQuote{Symbol: a.(string)}, true },
+		Schema:  certificateQuote{},
+	}
+	if err := RegisterAssetClass(certificate); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { unregisterAssetClass(certificate.Name) })
+	c, ok = LookupAssetClass("certificate")
+	if !ok || c.TableName != "certificate" || c.Title != "Certificate" {
+		t.Fatalf("registered %+v", c)
+	}
+	if a, err := c.Get(ctx, fixtureProvider{}, "CERT"); err != nil || a != "CERT" {
+		t.Errorf("fetched %v, %v through the asset class", a, err)
+	}
+	if cs := AssetClasses(); cs[len(cs)-1].Name != "Certificate" {
+		t.Errorf("asset classes end with %s, not the registered one", cs[len(cs)-1].Name)
+	}
+
+	for _, c := range []AssetClass{
+		certificate,
+		{Name: "stock", Aliases: []string{"EcnQuote"}, QuoteType: finance.QuoteTypeEquity, Convert: certificate.Convert},
+		{Name: "warrant", QuoteType: "WARRANT"},
+		{Name: "warrant", Convert: certificate.Convert},
+	} {
+		if err := RegisterAssetClass(c); err == nil {
+			t.Errorf("registered %s twice or without converter or source", c.Name)
+		}
+	}
+}
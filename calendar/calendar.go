@@ -0,0 +1,200 @@
+// Package calendar knows when exchanges trade. Calendars are rule
+// based: weekly trading days, daily trading hours and holidays computed
+// per year, including days on which the exchange closes early.
+package calendar
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata"
+)
+
+// Phases of the trading day a point in time can fall into.
+const (
+	Pre     = "pre"
+	Regular = "regular"
+	Post    = "post"
+	Closed  = "closed"
+)
+
+// Holiday is a day on which an exchange does not trade, or closes
+// early if EarlyClose is set.
+type Holiday struct {
+	Date       time.Time
+	Name       string
+	EarlyClose bool
+}
+
+// Calendar describes the trading days and hours of an exchange. The
+// hours are wall clock times of day in Location.
+type Calendar struct {
+	Name     string
+	Location *time.Location
+	AllWeek  bool
+
+	PreOpen    time.Duration
+	Open       time.Duration
+	Close      time.Duration
+	PostClose  time.Duration
+	EarlyClose time.Duration
+
+	// Holidays returns the holidays and early closes of year.
+	Holidays func(year int) []Holiday
+}
+
+// Session is a single trading day of a calendar.
+type Session struct {
+	Date       time.Time
+	PreOpen    time.Time
+	Open       time.Time
+	Close      time.Time
+	PostClose  time.Time
+	EarlyClose bool
+}
+
+func (c *Calendar) at(day time.Time, d time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, int(d/time.Second), 0, c.Location)
+}
+
+// Holiday returns the holiday on the day of t in the calendar's
+// timezone, if any.
+func (c *Calendar) Holiday(t time.Time) (Holiday, bool) {
+	if c.Holidays == nil {
+		return Holiday{}, false
+	}
+
+	t = t.In(c.Location)
+	for _, h := range c.Holidays(t.Year()) {
+		if h.Date.Year() == t.Year() && h.Date.YearDay() == t.YearDay() {
+			return h, true
+		}
+	}
+	return Holiday{}, false
+}
+
+// Session returns the trading session on the day of t in the calendar's
+// timezone; ok is false on weekends and holidays.
+func (c *Calendar) Session(t time.Time) (s Session, ok bool) {
+	t = t.In(c.Location)
+	if !c.AllWeek && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+		return
+	}
+
+	closeAt := c.Close
+	h, holiday := c.Holiday(t)
+	if holiday && !h.EarlyClose {
+		return
+	}
+	if holiday {
+		closeAt = c.EarlyClose
+	}
+
+	return Session{
+		Date:       c.at(t, 0),
+		PreOpen:    c.at(t, c.PreOpen),
+		Open:       c.at(t, c.Open),
+		Close:      c.at(t, closeAt),
+		PostClose:  c.at(t, closeAt+c.PostClose-c.Close),
+		EarlyClose: holiday,
+	}, true
+}
+
+// Sessions returns all trading sessions on the days from the day of
+// from up to and including the day of to.
+func (c *Calendar) Sessions(from, to time.Time) []Session {
+	ss := []Session{}
+	last := c.at(to.In(c.Location), 0)
+	for day := c.at(from.In(c.Location), 0); !day.After(last); day = day.AddDate(0, 0, 1) {
+		if s, ok := c.Session(day); ok {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// NextSession returns the first session that has not closed at t. It
+// gives up after a year without sessions.
+func (c *Calendar) NextSession(t time.Time) (Session, bool) {
+	day := c.at(t.In(c.Location), 0)
+	for i := 0; i < 366; i++ {
+		if s, ok := c.Session(day); ok && t.Before(s.Close) {
+			return s, true
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return Session{}, false
+}
+
+// Phase returns the phase of the trading day t falls into.
+func (c *Calendar) Phase(t time.Time) string {
+	s, ok := c.Session(t)
+	switch {
+	case !ok:
+		return Closed
+	case !t.Before(s.Open) && t.Before(s.Close):
+		return Regular
+	case !t.Before(s.PreOpen) && t.Before(s.Open):
+		return Pre
+	case !t.Before(s.Close) && t.Before(s.PostClose):
+		return Post
+	}
+	return Closed
+}
+
+// IsOpen reports whether the regular session is running at t.
+func (c *Calendar) IsOpen(t time.Time) bool {
+	return c.Phase(t) == Regular
+}
+
+var (
+	calendarsMu         sync.RWMutex
+	calendarsByExchange = map[string]*Calendar{}
+	calendarsByTimezone = map[string]*Calendar{}
+)
+
+// Register makes c available to Lookup under its name, the given
+// exchange names (as reported by Yahoo, e.g. NMS or NasdaqGS) and its
+// timezone, unless another calendar claimed that timezone first.
+func Register(c *Calendar, exchangeNames ...string) {
+	calendarsMu.Lock()
+	defer calendarsMu.Unlock()
+
+	for _, name := range append([]string{c.Name}, exchangeNames...) {
+		calendarsByExchange[strings.ToUpper(name)] = c
+	}
+	if _, ok := calendarsByTimezone[c.Location.String()]; !ok {
+		calendarsByTimezone[c.Location.String()] = c
+	}
+}
+
+// Lookup finds the calendar of an exchange by its name, falling back to
+// the calendar registered for its timezone.
+func Lookup(exchangeName, timezone string) (*Calendar, bool) {
+	calendarsMu.RLock()
+	defer calendarsMu.RUnlock()
+
+	if c, ok := calendarsByExchange[strings.ToUpper(exchangeName)]; ok {
+		return c, true
+	}
+	c, ok := calendarsByTimezone[timezone]
+	return c, ok
+}
+
+// Calendars returns all registered calendars ordered by name.
+func Calendars() []*Calendar {
+	calendarsMu.RLock()
+	defer calendarsMu.RUnlock()
+
+	seen := map[*Calendar]bool{}
+	cs := []*Calendar{}
+	for _, c := range calendarsByExchange {
+		if !seen[c] {
+			seen[c] = true
+			cs = append(cs, c)
+		}
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].Name < cs[j].Name })
+	return cs
+}
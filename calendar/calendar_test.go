@@ -0,0 +1,77 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHolidays(t *testing.T) {
+	for _, c := range []struct {
+		cal   *Calendar
+		day   time.Time
+		open  bool
+		early bool
+	}{
+		{NYSE, date(2024, time.March, 29), false, false},    // Good Friday
+		{NYSE, date(2024, time.July, 3), true, true},        // Independence Day eve
+		{NYSE, date(2020, time.July, 3), false, false},      // Independence Day observed
+		{NYSE, date(2024, time.November, 29), true, true},   // Day after Thanksgiving
+		{NYSE, date(2021, time.December, 24), false, false}, // Christmas observed
+		{NYSE, date(2021, time.December, 31), true, false},  // New Year's Day on Saturday is not observed
+		{NYSE, date(2023, time.June, 19), false, false},     // Juneteenth
+		{NYSE, date(2024, time.March, 28), true, false},
+		{XETRA, date(2024, time.April, 1), false, false},   // Easter Monday
+		{XETRA, date(2024, time.May, 20), true, false},     // Whit Monday is traded
+		{LSE, date(2021, time.December, 28), false, false}, // Boxing Day observed
+		{LSE, date(2024, time.December, 24), true, true},
+		{LSE, date(2024, time.August, 26), false, false},     // Summer Bank Holiday
+		{Crypto, date(2024, time.December, 25), true, false}, // Crypto never closes
+	} {
+		s, ok := c.cal.Session(c.cal.at(c.day, 12*time.Hour))
+		if ok != c.open || s.EarlyClose != c.early {
+			t.Errorf("%s on %s: open %v, early close %v; want %v, %v", c.cal.Name, c.day.Format("2006-01-02"), ok, s.EarlyClose, c.open, c.early)
+		}
+	}
+}
+
+func TestSessions(t *testing.T) {
+	// Nine trading days: the 4th of July and the weekend are closed.
+	ss := NYSE.Sessions(NYSE.at(date(2024, time.July, 1), 0), NYSE.at(date(2024, time.July, 12), 0))
+	if len(ss) != 9 {
+		t.Fatalf("got %d sessions, want 9", len(ss))
+	}
+	if s := ss[2]; !s.EarlyClose || s.Close.Hour() != 13 {
+		t.Errorf("July 3rd closes at %v", s.Close)
+	}
+	if s := ss[0]; s.Open.Hour() != 9 || s.Open.Minute() != 30 || s.Open.Location() != NYSE.Location {
+		t.Errorf("session opens at %v", s.Open)
+	}
+
+	friday := time.Date(2024, time.July, 5, 17, 0, 0, 0, NYSE.Location)
+	if s, ok := NYSE.NextSession(friday); !ok || s.Date.Day() != 8 {
+		t.Errorf("next session after Friday's close is on %v", s.Date)
+	}
+	if p := NYSE.Phase(friday); p != Post {
+		t.Errorf("Phase(%v) = %s, want %s", friday, p, Post)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, c := range []struct {
+		exchange, timezone string
+		want               *Calendar
+	}{
+		{"NMS", "America/New_York", NYSE},
+		{"nasdaqgs", "", NYSE},
+		{"GER", "Europe/Berlin", XETRA},
+		{"", "Europe/London", LSE},
+		{"CCC", "UTC", Crypto},
+	} {
+		if got, ok := Lookup(c.exchange, c.timezone); !ok || got != c.want {
+			t.Errorf("Lookup(%q, %q) = %v", c.exchange, c.timezone, got)
+		}
+	}
+	if _, ok := Lookup("TYO", "Asia/Tokyo"); ok {
+		t.Error("found a calendar for an unknown exchange")
+	}
+}
@@ -0,0 +1,141 @@
+package calendar
+
+import "time"
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// NYSE covers the US equity markets (NYSE and Nasdaq).
+var NYSE = &Calendar{
+	Name:       "NYSE",
+	Location:   mustLoadLocation("America/New_York"),
+	PreOpen:    4 * time.Hour,
+	Open:       9*time.Hour + 30*time.Minute,
+	Close:      16 * time.Hour,
+	PostClose:  20 * time.Hour,
+	EarlyClose: 13 * time.Hour,
+	Holidays:   nyseHolidays,
+}
+
+func nyseHolidays(year int) []Holiday {
+	hs := []Holiday{}
+	if d := date(year, time.January, 1); d.Weekday() != time.Saturday {
+		hs = append(hs, Holiday{Date: observedNearest(d), Name: "New Year's Day"})
+	}
+	if year >= 1998 {
+		hs = append(hs, Holiday{Date: nthWeekday(year, time.January, time.Monday, 3), Name: "Martin Luther King Jr. Day"})
+	}
+	hs = append(hs,
+		Holiday{Date: nthWeekday(year, time.February, time.Monday, 3), Name: "Washington's Birthday"},
+		Holiday{Date: easter(year).AddDate(0, 0, -2), Name: "Good Friday"},
+		Holiday{Date: nthWeekday(year, time.May, time.Monday, -1), Name: "Memorial Day"},
+	)
+	if year >= 2022 {
+		hs = append(hs, Holiday{Date: observedNearest(date(year, time.June, 19)), Name: "Juneteenth"})
+	}
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	hs = append(hs,
+		Holiday{Date: observedNearest(date(year, time.July, 4)), Name: "Independence Day"},
+		Holiday{Date: nthWeekday(year, time.September, time.Monday, 1), Name: "Labor Day"},
+		Holiday{Date: thanksgiving, Name: "Thanksgiving Day"},
+		Holiday{Date: observedNearest(date(year, time.December, 25)), Name: "Christmas Day"},
+	)
+
+	// Early closes come last, so a full holiday on the same day wins.
+	for _, h := range []Holiday{
+		{Date: date(year, time.July, 3), Name: "Independence Day", EarlyClose: true},
+		{Date: thanksgiving.AddDate(0, 0, 1), Name: "Thanksgiving Day", EarlyClose: true},
+		{Date: date(year, time.December, 24), Name: "Christmas Eve", EarlyClose: true},
+	} {
+		if isWeekday(h.Date) {
+			hs = append(hs, h)
+		}
+	}
+	return hs
+}
+
+// XETRA covers Deutsche Börse's electronic trading venue.
+var XETRA = &Calendar{
+	Name:      "XETRA",
+	Location:  mustLoadLocation("Europe/Berlin"),
+	PreOpen:   9 * time.Hour,
+	Open:      9 * time.Hour,
+	Close:     17*time.Hour + 30*time.Minute,
+	PostClose: 17*time.Hour + 30*time.Minute,
+	Holidays:  xetraHolidays,
+}
+
+func xetraHolidays(year int) []Holiday {
+	return []Holiday{
+		{Date: date(year, time.January, 1), Name: "New Year's Day"},
+		{Date: easter(year).AddDate(0, 0, -2), Name: "Good Friday"},
+		{Date: easter(year).AddDate(0, 0, 1), Name: "Easter Monday"},
+		{Date: date(year, time.May, 1), Name: "Labour Day"},
+		{Date: date(year, time.December, 24), Name: "Christmas Eve"},
+		{Date: date(year, time.December, 25), Name: "Christmas Day"},
+		{Date: date(year, time.December, 26), Name: "Boxing Day"},
+		{Date: date(year, time.December, 31), Name: "New Year's Eve"},
+	}
+}
+
+// LSE covers the London Stock Exchange. One-off bank holidays such as
+// royal events are not part of its rules.
+var LSE = &Calendar{
+	Name:       "LSE",
+	Location:   mustLoadLocation("Europe/London"),
+	PreOpen:    8 * time.Hour,
+	Open:       8 * time.Hour,
+	Close:      16*time.Hour + 30*time.Minute,
+	PostClose:  16*time.Hour + 30*time.Minute,
+	EarlyClose: 12*time.Hour + 30*time.Minute,
+	Holidays:   lseHolidays,
+}
+
+func lseHolidays(year int) []Holiday {
+	christmas := observedMonday(date(year, time.December, 25))
+	boxing := observedMonday(date(year, time.December, 26))
+	if boxing.Equal(christmas) {
+		boxing = boxing.AddDate(0, 0, 1)
+	}
+
+	hs := []Holiday{
+		{Date: observedMonday(date(year, time.January, 1)), Name: "New Year's Day"},
+		{Date: easter(year).AddDate(0, 0, -2), Name: "Good Friday"},
+		{Date: easter(year).AddDate(0, 0, 1), Name: "Easter Monday"},
+		{Date: nthWeekday(year, time.May, time.Monday, 1), Name: "Early May Bank Holiday"},
+		{Date: nthWeekday(year, time.May, time.Monday, -1), Name: "Spring Bank Holiday"},
+		{Date: nthWeekday(year, time.August, time.Monday, -1), Name: "Summer Bank Holiday"},
+		{Date: christmas, Name: "Christmas Day"},
+		{Date: boxing, Name: "Boxing Day"},
+	}
+	for _, h := range []Holiday{
+		{Date: date(year, time.December, 24), Name: "Christmas Eve", EarlyClose: true},
+		{Date: date(year, time.December, 31), Name: "New Year's Eve", EarlyClose: true},
+	} {
+		if isWeekday(h.Date) {
+			hs = append(hs, h)
+		}
+	}
+	return hs
+}
+
+// Crypto trades around the clock on every day of the year.
+var Crypto = &Calendar{
+	Name:      "Crypto",
+	Location:  time.UTC,
+	AllWeek:   true,
+	Close:     24 * time.Hour,
+	PostClose: 24 * time.Hour,
+}
+
+func init() {
+	Register(NYSE, "NYQ", "NMS", "NGM", "NCM", "NasdaqGS", "NasdaqGM", "NasdaqCM", "Nasdaq", "NYSEArca", "PCX", "ASE", "BTS", "NIM", "SNP", "DJI")
+	Register(XETRA, "GER")
+	Register(LSE)
+	Register(Crypto, "CCC")
+}
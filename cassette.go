@@ -0,0 +1,245 @@
+package odbc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piquette/finance-go"
+	"github.com/piquette/finance-go/chart"
+)
+
+// A cassette stores provider responses as JSON files below a directory,
+// one file per call: <dir>/<kind>/<symbol>[_<interval>]_<n>.json where n
+// counts identical calls within a run. Replaying serves the n-th recorded
+// response to the n-th identical call, so runs stay reproducible even
+// though chart windows are computed relative to the current time.
+type cassette struct {
+	dir string
+
+	mu   sync.Mutex
+	seen map[string]int
+}
+
+type cassetteEntry struct {
+	Value json.RawMessage `json:"value"`
+	Err   string          `json:"error,omitempty"`
+}
+
+type cassetteChart struct {
+	Meta finance.ChartMeta  `json:"meta"`
+	Bars []finance.ChartBar `json:"bars"`
+	Err  string             `json:"error,omitempty"`
+}
+
+func (c *cassette) next(kind string, parts ...string) string {
+	name := url.PathEscape(strings.Join(parts, "_"))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.seen == nil {
+		c.seen = map[string]int{}
+	}
+	key := filepath.Join(kind, name)
+	n := c.seen[key]
+	c.seen[key] = n + 1
+
+	return filepath.Join(c.dir, kind, fmt.Sprintf("%s_%d.json", name, n))
+}
+
+func (c *cassette) write(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func (c *cassette) read(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("cassette: no recording at %s", path)
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (c *cassette) writeEntry(path string, value interface{}, err error) error {
+	e := cassetteEntry{}
+	if err != nil {
+		e.Err = err.Error()
+	}
+
+	data, merr := json.Marshal(value)
+	if merr != nil {
+		return merr
+	}
+	e.Value = data
+
+	return c.write(path, e)
+}
+
+func (c *cassette) readEntry(path string, value interface{}) error {
+	e := cassetteEntry{}
+	if err := c.read(path, &e); err != nil {
+		return err
+	}
+	if len(e.Value) != 0 {
+		if err := json.Unmarshal(e.Value, value); err != nil {
+			return err
+		}
+	}
+	if e.Err != "" {
+		return errors.New(e.Err)
+	}
+	return nil
+}
+
+func chartCassetteParts(params *chart.Params) []string {
+	return []string{params.Symbol, string(params.Interval)}
+}
+
+// RecordingProvider forwards every call to the wrapped Provider and
+// stores the response in a cassette directory for later replay.
+type RecordingProvider struct {
+	Provider
+
+	cassette cassette
+}
+
+func NewRecordingProvider(p Provider, dir string) *RecordingProvider {
+	return &RecordingProvider{
+		Provider: p,
+		cassette: cassette{dir: dir},
+	}
+}
+
+func (r *RecordingProvider) GetQuote(ctx context.Context, symbol string) (*finance.Quote, error) {
+	q, err := r.Provider.GetQuote(ctx, symbol)
+	if werr := r.cassette.writeEntry(r.cassette.next("quote", symbol), q, err); werr != nil {
+		return nil, werr
+	}
+	return q, err
+}
+
+func (r *RecordingProvider) GetAsset(ctx context.Context, quoteType finance.QuoteType, symbol string) (interface{}, error) {
+	a, err := r.Provider.GetAsset(ctx, quoteType, symbol)
+	if werr := r.cassette.writeEntry(r.cassette.next(strings.ToLower(string(quoteType)), symbol), a, err); werr != nil {
+		return nil, werr
+	}
+	return a, err
+}
+
+func (r *RecordingProvider) GetChart(ctx context.Context, params *chart.Params) ChartIterator {
+	path := r.cassette.next("chart", chartCassetteParts(params)...)
+
+	iter := r.Provider.GetChart(ctx, params)
+	c := cassetteChart{
+		Bars: []finance.ChartBar{},
+	}
+	for iter.Next() {
+		c.Bars = append(c.Bars, *iter.Bar())
+	}
+	c.Meta = iter.Meta()
+	if err := iter.Err(); err != nil {
+		c.Err = err.Error()
+	}
+
+	if err := r.cassette.write(path, c); err != nil {
+		return NewChartSlice(finance.ChartMeta{}, nil, err)
+	}
+	return NewChartSlice(c.Meta, c.Bars, iter.Err())
+}
+
+func (r *RecordingProvider) GetCorporateActions(ctx context.Context, symbol string, start, end time.Time) ([]CorporateAction, error) {
+	p, ok := r.Provider.(CorporateActionProvider)
+	if !ok {
+		return nil, fmt.Errorf("cassette: recorded provider cannot list corporate actions")
+	}
+
+	as, err := p.GetCorporateActions(ctx, symbol, start, end)
+	if werr := r.cassette.writeEntry(r.cassette.next("actions", symbol), as, err); werr != nil {
+		return nil, werr
+	}
+	return as, err
+}
+
+// ReplayProvider serves responses previously stored by a
+// RecordingProvider without touching the network.
+type ReplayProvider struct {
+	cassette cassette
+}
+
+func NewReplayProvider(dir string) *ReplayProvider {
+	return &ReplayProvider{
+		cassette: cassette{dir: dir},
+	}
+}
+
+func (r *ReplayProvider) GetQuote(ctx context.Context, symbol string) (*finance.Quote, error) {
+	var q *finance.Quote
+	if err := r.cassette.readEntry(r.cassette.next("quote", symbol), &q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (r *ReplayProvider) GetAsset(ctx context.Context, quoteType finance.QuoteType, symbol string) (interface{}, error) {
+	path := r.cassette.next(strings.ToLower(string(quoteType)), symbol)
+
+	class, ok := LookupAssetClass(string(quoteType))
+	if !ok || class.NewAPI == nil {
+		return nil, fmt.Errorf("cassette: unsupported quote type %s", quoteType)
+	}
+	a := class.NewAPI()
+
+	e := cassetteEntry{}
+	if err := r.cassette.read(path, &e); err != nil {
+		return nil, err
+	}
+	if e.Err != "" {
+		return nil, errors.New(e.Err)
+	}
+	if len(e.Value) == 0 || string(e.Value) == "null" {
+		return nil, notFound(nil, strings.ToLower(string(quoteType)), symbol)
+	}
+	if err := json.Unmarshal(e.Value, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *ReplayProvider) GetChart(ctx context.Context, params *chart.Params) ChartIterator {
+	c := cassetteChart{}
+	if err := r.cassette.read(r.cassette.next("chart", chartCassetteParts(params)...), &c); err != nil {
+		return NewChartSlice(finance.ChartMeta{}, nil, err)
+	}
+
+	var err error
+	if c.Err != "" {
+		err = errors.New(c.Err)
+	}
+	return NewChartSlice(c.Meta, c.Bars, err)
+}
+
+func (r *ReplayProvider) GetCorporateActions(ctx context.Context, symbol string, start, end time.Time) ([]CorporateAction, error) {
+	as := []CorporateAction{}
+	if err := r.cassette.readEntry(r.cassette.next("actions", symbol), &as); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
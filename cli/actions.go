@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+)
+
+// fetchCorporateActions downloads the dividends and splits of symbols
+// between from and to and stores them in sink.
+func fetchCorporateActions(ctx context.Context, sink fodbc.Sink, symbols []string, from, to time.Time) error {
+	actions, ok := provider.(fodbc.CorporateActionProvider)
+	if !ok {
+		return fmt.Errorf("provider cannot list corporate actions")
+	}
+	store, ok := sink.(fodbc.CorporateActionStore)
+	if !ok {
+		return fmt.Errorf("sink %s cannot store corporate actions", *sinkFlag)
+	}
+
+	return fetchAll(ctx, "actions", symbols, func(ctx context.Context, symbol string) (func(context.Context) error, error) {
+		as, err := actions.GetCorporateActions(ctx, symbol, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error {
+			return store.WriteCorporateActions(ctx, as)
+		}, nil
+	})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// checkpoint lists the downloads an interrupted run completed, by step
+// and symbol, so that the next run of the same command line can skip
+// them.
+type checkpoint struct {
+	CommandLine []string            `json:"command_line"`
+	SavedAt     time.Time           `json:"saved_at"`
+	Completed   map[string][]string `json:"completed"`
+
+	done map[[2]string]bool
+}
+
+var (
+	commandLine = []string{}
+	resumed     *checkpoint
+	interrupted = 0
+)
+
+func (c *checkpoint) completed(step, symbol string) bool {
+	if c == nil {
+		return false
+	}
+	if c.done == nil {
+		c.done = map[[2]string]bool{}
+		for step, symbols := range c.Completed {
+			for _, s := range symbols {
+				c.done[[2]string{step, s}] = true
+			}
+		}
+	}
+	return c.done[[2]string{step, symbol}]
+}
+
+// loadCheckpoint returns the checkpoint at path if it was saved by an
+// interrupted run of commandLine; checkpoints of other command lines
+// are ignored and replaced once this one is interrupted.
+func loadCheckpoint(path string, commandLine []string) (*checkpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checkpoint{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if strings.Join(c.CommandLine, "\x00") != strings.Join(commandLine, "\x00") {
+		return nil, nil
+	}
+	return c, nil
+}
+
+// saveCheckpoint writes the downloads completed by this run and the one
+// it resumed to path.
+func saveCheckpoint(path string, commandLine []string) error {
+	c := checkpoint{
+		CommandLine: commandLine,
+		SavedAt:     time.Now().UTC(),
+		Completed:   map[string][]string{},
+	}
+	if resumed != nil {
+		for step, symbols := range resumed.Completed {
+			c.Completed[step] = append(c.Completed[step], symbols...)
+		}
+	}
+	for _, o := range outcomes {
+		if o.Error == "" {
+			c.Completed[o.Step] = append(c.Completed[o.Step], o.Symbol)
+		}
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// detached keeps the values of a context but not its cancellation, so
+// the writes of data fetched before an interrupt still complete.
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detached) Done() <-chan struct{}       { return nil }
+func (detached) Err() error                  { return nil }
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	fodbc "github.com/jakoblorz/finance-odbc"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of the file given with -config, written in YAML
+// or TOML depending on its extension. Flags given on the command line
+// override it.
+//
+//	sink: sqlite://finance.sqlite3
+//	precision:
+//	  JPY: 0
+//	watchlists:
+//	  tech: [AAPL, MSFT]
+//	quotes:
+//	  equity: [tech]
+//	  index: [^GDAXI]
+//	ticks:
+//	  symbols: [tech]
+//	  intervals: [1d, 1h]
+//	  from: 1y
+//	schedule:
+//	  - watchlist: tech
+//	    quotes: "CRON_TZ=America/New_York * 9-15 * * 1-5"
+//	    ticks: "CRON_TZ=America/New_York 30 17 * * 1-5"
+type Config struct {
+	Sink   string `yaml:"sink" toml:"sink"`
+	Record string `yaml:"record" toml:"record"`
+	Replay string `yaml:"replay" toml:"replay"`
+
+	// Concurrency is the number of symbols downloaded at once, Rate the
+	// number of API requests per second they share. Retries is the
+	// number of tries of calls failing with transient errors.
+	Concurrency int     `yaml:"concurrency" toml:"concurrency"`
+	Rate        float64 `yaml:"rate" toml:"rate"`
+	Retries     int     `yaml:"retries" toml:"retries"`
+
+	// BatchSize is the number of rows inserted per statement.
+	BatchSize int `yaml:"batch_size" toml:"batch_size"`
+
+	// Checkpoint is the file the downloads completed before an interrupt
+	// are remembered in.
+	Checkpoint string `yaml:"checkpoint" toml:"checkpoint"`
+
+	// Precision is the number of decimal places prices are rounded to
+	// by currency, see fodbc.SetCurrencyPrecision.
+	Precision map[string]int32 `yaml:"precision" toml:"precision"`
+
+	// Watchlists are named groups of symbols, usable wherever symbols
+	// are expected.
+	Watchlists map[string][]string `yaml:"watchlists" toml:"watchlists"`
+
+	// Quotes lists the symbols the quotes command downloads by asset
+	// class.
+	Quotes map[string][]string `yaml:"quotes" toml:"quotes"`
+	Ticks  TicksConfig         `yaml:"ticks" toml:"ticks"`
+
+	Schedule []ScheduleConfig `yaml:"schedule" toml:"schedule"`
+}
+
+// TicksConfig holds the defaults of the commands working on prices.
+type TicksConfig struct {
+	Symbols   []string `yaml:"symbols" toml:"symbols"`
+	Intervals []string `yaml:"intervals" toml:"intervals"`
+	From      string   `yaml:"from" toml:"from"`
+	To        string   `yaml:"to" toml:"to"`
+
+	Incremental bool `yaml:"incremental" toml:"incremental"`
+	Upsert      bool `yaml:"upsert" toml:"upsert"`
+	Backfill    bool `yaml:"backfill" toml:"backfill"`
+	PrePost     bool `yaml:"prepost" toml:"prepost"`
+	Regular     bool `yaml:"regular" toml:"regular"`
+	Actions     bool `yaml:"actions" toml:"actions"`
+	Ranges      bool `yaml:"ranges" toml:"ranges"`
+}
+
+// ScheduleConfig describes when the symbols of a watchlist are
+// collected by the run command, as a cron expression per kind of data:
+// five fields (minute, hour, day of month, month and day of week),
+// optionally preceded by CRON_TZ=<timezone>, or a descriptor such as
+// @hourly. Either may be left empty.
+type ScheduleConfig struct {
+	Watchlist string `yaml:"watchlist" toml:"watchlist"`
+	Quotes    string `yaml:"quotes" toml:"quotes"`
+	Ticks     string `yaml:"ticks" toml:"ticks"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Sink:        "sqlite://finance.sqlite3",
+		Concurrency: 4,
+		Rate:        5,
+		Retries:     fodbc.DefaultRetryPolicy.Attempts,
+		BatchSize:   fodbc.DefaultBatchSize,
+		Checkpoint:  "finance-odbc.checkpoint.json",
+		Ticks: TicksConfig{
+			From:        "100h",
+			To:          "now",
+			Incremental: true,
+		},
+	}
+}
+
+// loadConfig reads the config file at path over the defaults. An empty
+// path yields the defaults.
+func loadConfig(path string) (*Config, error) {
+	cfg := defaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		d := yaml.NewDecoder(bytes.NewReader(data))
+		d.KnownFields(true)
+		if err := d.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("%s: unknown key %s", path, undecoded[0])
+		}
+	default:
+		return nil, fmt.Errorf("%s: config files must end in .yaml, .yml or .toml", path)
+	}
+	return cfg, cfg.validate()
+}
+
+func (c *Config) validate() error {
+	for currency, places := range c.Precision {
+		if places < 0 {
+			return fmt.Errorf("precision: %s: negative number of decimal places %d", currency, places)
+		}
+	}
+	for class := range c.Quotes {
+		if _, ok := fodbc.LookupAssetClass(class); !ok {
+			return fmt.Errorf("quotes: unknown asset class %q", class)
+		}
+	}
+	// The watchlists of the schedule may be stored in the sink, so
+	// runRun looks them up.
+	for _, s := range c.Schedule {
+		if s.Quotes == "" && s.Ticks == "" {
+			return fmt.Errorf("schedule: neither quotes nor ticks given for %s", s.Watchlist)
+		}
+		for kind, expr := range map[string]string{"quotes": s.Quotes, "ticks": s.Ticks} {
+			if expr == "" {
+				continue
+			}
+			if _, err := cron.ParseStandard(expr); err != nil {
+				return fmt.Errorf("schedule: %s %s: %s", s.Watchlist, kind, err)
+			}
+		}
+	}
+	return nil
+}
+
+// expand replaces the watchlist names among symbols by their members.
+func (c *Config) expand(symbols []string) []string {
+	expanded := []string{}
+	for _, s := range symbols {
+		if members, ok := c.Watchlists[s]; ok {
+			expanded = append(expanded, members...)
+			continue
+		}
+		expanded = append(expanded, s)
+	}
+	return expanded
+}
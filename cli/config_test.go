@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	yaml := writeConfig(t, "finance.yaml", `
+sink: file://out
+precision:
+  JPY: 0
+watchlists:
+  tech: [AAPL, MSFT]
+quotes:
+  equity: [tech, SAP]
+ticks:
+  symbols: [tech]
+  intervals: [1d]
+  incremental: false
+schedule:
+  - watchlist: tech
+    ticks: "30 22 * * 1-5"
+`)
+	toml := writeConfig(t, "finance.toml", `
+sink = "file://out"
+[precision]
+JPY = 0
+[watchlists]
+tech = ["AAPL", "MSFT"]
+[quotes]
+equity = ["tech", "SAP"]
+[ticks]
+symbols = ["tech"]
+intervals = ["1d"]
+incremental = false
+[[schedule]]
+watchlist = "tech"
+ticks = "30 22 * * 1-5"
+`)
+
+	for _, path := range []string{yaml, toml} {
+		cfg, err := loadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		if cfg.Sink != "file://out" || cfg.Ticks.From != "100h" || cfg.Ticks.Incremental {
+			t.Errorf("%s: unexpected config %+v", path, cfg)
+		}
+		if places, ok := cfg.Precision["JPY"]; !ok || places != 0 {
+			t.Errorf("%s: unexpected precision %v", path, cfg.Precision)
+		}
+		if got := cfg.expand(cfg.Quotes["equity"]); !reflect.DeepEqual(got, []string{"AAPL", "MSFT", "SAP"}) {
+			t.Errorf("%s: equities expand to %v", path, got)
+		}
+		if len(cfg.Schedule) != 1 || cfg.Schedule[0].Ticks != "30 22 * * 1-5" {
+			t.Errorf("%s: unexpected schedule %+v", path, cfg.Schedule)
+		}
+	}
+
+	for content, want := range map[string]string{
+		"sinks: file://out\n":        "sinks",
+		"precision:\n  JPY: -1\n":    "JPY",
+		"quotes:\n  stock: [AAPL]\n": "stock",
+		"watchlists:\n  dax: [SAP]\nschedule:\n  - watchlist: dax\n    quotes: \"61 * * * *\"\n": "quotes",
+		"watchlists:\n  dax: [SAP]\nschedule:\n  - watchlist: dax\n":                             "neither",
+	} {
+		if _, err := loadConfig(writeConfig(t, "finance.yml", content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("loading %q failed with %v, want an error about %s", content, err, want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+	"github.com/robfig/cron/v3"
+)
+
+func runFlags(fs *flag.FlagSet, cfg *Config) {
+	rangeFlags(fs, cfg, cfg.Ticks.From)
+	downloadFlags(fs, cfg)
+	fs.BoolVar(openFlag, "open", true, "Skip the quotes of symbols whose market is closed")
+	fs.BoolVar(daemonFlag, "daemon", false, "Keep running and collect every watchlist whenever its schedule is due")
+}
+
+// job collects one kind of data, quotes or ticks, of a watchlist of the
+// schedule.
+type job struct {
+	watchlist string
+	kind      string
+	schedule  cron.Schedule
+	next      time.Time
+}
+
+func (j *job) String() string {
+	return fmt.Sprintf("%s of %s", j.kind, j.watchlist)
+}
+
+func (j *job) run(ctx context.Context, sink fodbc.Sink, cfg *Config) error {
+	print(fmt.Sprintf("Collecting %s\n", j))
+	*watchlistFlag = j.watchlist
+	if j.kind == "quotes" {
+		assetFlags = map[string]*string{}
+		return runQuotes(ctx, sink, cfg, nil)
+	}
+	return runTicks(ctx, sink, cfg, nil)
+}
+
+func scheduledJobs(cfg *Config) ([]*job, error) {
+	jobs := []*job{}
+	for _, s := range cfg.Schedule {
+		for _, kind := range [][2]string{{"quotes", s.Quotes}, {"ticks", s.Ticks}} {
+			if kind[1] == "" {
+				continue
+			}
+			schedule, err := cron.ParseStandard(kind[1])
+			if err != nil {
+				return nil, fmt.Errorf("schedule: %s %s: %s", s.Watchlist, kind[0], err)
+			}
+			jobs = append(jobs, &job{watchlist: s.Watchlist, kind: kind[0], schedule: schedule})
+		}
+	}
+	return jobs, nil
+}
+
+// runRun collects every watchlist of the schedule in the config once.
+// With -daemon it keeps running instead and collects each kind of data
+// of a watchlist whenever its cron expression is due, until it is
+// interrupted. Every round is reported on its own, and failed downloads
+// do not stop the daemon; as the next round downloads anew, no
+// checkpoint is left behind.
+func runRun(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("run takes no symbols, it collects the watchlists of the schedule in the config")
+	}
+	jobs, err := scheduledJobs(cfg)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("no schedule given, add one to the config given with -config")
+	}
+	for _, j := range jobs {
+		if _, err := loadWatchlist(ctx, sink, cfg, j.watchlist); err != nil {
+			return fmt.Errorf("schedule: %s", err)
+		}
+	}
+
+	if !*daemonFlag {
+		for _, j := range jobs {
+			if err := j.run(ctx, sink, cfg); err != nil {
+				return fmt.Errorf("%s: %s", j, err)
+			}
+		}
+		return nil
+	}
+
+	resumed = nil
+	now := time.Now()
+	for _, j := range jobs {
+		j.next = j.schedule.Next(now)
+	}
+	for {
+		due := []*job{}
+		for _, j := range jobs {
+			if !j.next.IsZero() {
+				due = append(due, j)
+			}
+		}
+		if jobs = due; len(jobs) == 0 {
+			return fmt.Errorf("no more collections scheduled")
+		}
+		sort.SliceStable(jobs, func(i, k int) bool { return jobs[i].next.Before(jobs[k].next) })
+
+		next, names := jobs[0].next, []string{}
+		for _, j := range jobs {
+			if j.next.Equal(next) {
+				names = append(names, j.String())
+			}
+		}
+		print(fmt.Sprintf("Next collection at %s: %s\n", next.Local().Format(time.RFC1123), strings.Join(names, ", ")))
+
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		}
+
+		ferr := runRound(ctx, sink, cfg, jobs, time.Now())
+		if ctx.Err() != nil {
+			interrupted = 0
+			return nil
+		}
+
+		printWarnings()
+		if err := report(); err != nil {
+			print(fmt.Sprintf("%s\n", err))
+		}
+		if ferr != nil {
+			print(fmt.Sprintf("Writing the collections to the sink failed: %s\n", ferr))
+		}
+		warningsMu.Lock()
+		warnings = []string{}
+		warningsMu.Unlock()
+		outcomes = []outcome{}
+	}
+}
+
+// runRound collects the jobs due at now and flushes the sink, so a
+// daemon keeps what it collected even if it never exits cleanly. The
+// error is the one of the flush; failed collections are warned about.
+func runRound(ctx context.Context, sink fodbc.Sink, cfg *Config, jobs []*job, now time.Time) error {
+	for _, j := range jobs {
+		if j.next.After(now) || ctx.Err() != nil {
+			continue
+		}
+		if err := j.run(ctx, sink, cfg); err != nil {
+			warn(fmt.Sprintf("Collecting %s failed: %s", j, err))
+		}
+		j.next = j.schedule.Next(time.Now())
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return sink.Flush(ctx)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+)
+
+func exportFlags(fs *flag.FlagSet, cfg *Config) {
+	priceFlags(fs, cfg, "max")
+	fs.StringVar(formatFlag, "format", "csv", "Output format: csv or jsonl")
+	fs.StringVar(outFlag, "o", "", "Write to this file instead of stdout")
+}
+
+// runExport writes the stored prices of the given symbols at every
+// selected interval between -from and -to.
+func runExport(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) error {
+	batches, from, to, err := selectPrices(ctx, sink, cfg, args)
+	if err != nil {
+		return err
+	}
+	reader, ok := sink.(fodbc.TickReader)
+	if !ok {
+		return fmt.Errorf("sink %s cannot read stored prices", *sinkFlag)
+	}
+
+	var out io.Writer = os.Stdout
+	if *outFlag != "" {
+		f, err := os.Create(*outFlag)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var write func(t *fodbc.Tick) error
+	var flush func() error
+	switch *formatFlag {
+	case "csv":
+		w := csv.NewWriter(out)
+		if err := w.Write(exportColumns); err != nil {
+			return err
+		}
+		write = func(t *fodbc.Tick) error { return w.Write(exportRecord(t)) }
+		flush = func() error { w.Flush(); return w.Error() }
+	case "jsonl":
+		enc := json.NewEncoder(out)
+		write = func(t *fodbc.Tick) error { return enc.Encode(t) }
+		flush = func() error { return nil }
+	default:
+		return fmt.Errorf("unknown format %q, use csv or jsonl", *formatFlag)
+	}
+
+	for _, batch := range batches {
+		for _, value := range batch.symbols {
+			ticks, err := reader.ReadTicks(ctx, value, batch.interval)
+			if err != nil {
+				return err
+			}
+			for i := range ticks {
+				if ticks[i].Timestamp.Before(from) || ticks[i].Timestamp.After(to) {
+					continue
+				}
+				if *regularFlag && ticks[i].Session != fodbc.SessionRegular {
+					continue
+				}
+				if err := write(&ticks[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return flush()
+}
+
+var exportColumns = []string{"symbol", "granularity", "timestamp", "session", "open", "high", "low", "close", "adj_close", "volume", "currency"}
+
+func exportRecord(t *fodbc.Tick) []string {
+	return []string{
+		t.Symbol,
+		t.Granularity,
+		t.Timestamp.Format(time.RFC3339),
+		t.Session,
+		t.Open.String(),
+		t.High.String(),
+		t.Low.String(),
+		t.Close.String(),
+		t.AdjClose.String(),
+		strconv.Itoa(t.Volume),
+		t.Currency,
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+)
+
+func gapsFlags(fs *flag.FlagSet, cfg *Config) {
+	priceFlags(fs, cfg, cfg.Ticks.From)
+	fs.BoolVar(fillFlag, "fill", false, "Download the missing bars again")
+}
+
+// runGaps checks the stored prices of the given symbols at every
+// selected interval for gaps and lists them.
+func runGaps(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) error {
+	batches, from, to, err := selectPrices(ctx, sink, cfg, args)
+	if err != nil {
+		return err
+	}
+
+	tickStats := fodbc.WriteStats{}
+	gaps := []fodbc.Gap{}
+	for _, batch := range batches {
+		interval, values := batch.interval, batch.symbols
+		cancel := spin(
+			fmt.Sprintf("Checking Historical Prices with an interval of %s:%s for gaps ", interval, tickIntervalPadding[interval]),
+			"",
+		)
+		cancel(fetchAll(ctx, interval, values, func(ctx context.Context, value string) (func(context.Context) error, error) {
+			found, fills, err := findGaps(ctx, sink, value, interval, from, to)
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context) error {
+				gaps = append(gaps, found...)
+				if len(fills) == 0 {
+					return nil
+				}
+				stats, err := sink.WriteTicks(ctx, fills, fodbc.KeepStored)
+				tickStats.Add(stats)
+				return err
+			}, nil
+		}))
+	}
+
+	print(fmt.Sprintf("Gaps: %d\n", len(gaps)))
+	for _, g := range gaps {
+		print(fmt.Sprintf("  %s\n", g))
+	}
+	print(fmt.Sprintf("Ticks: %s\n", tickStats))
+	return nil
+}
+
+// findGaps compares the stored bars of symbol at interval against the
+// trading sessions of its exchange between from and to. With -fill the
+// missing bars are downloaded again right away and returned as fills.
+func findGaps(ctx context.Context, sink fodbc.Sink, symbol, interval string, from, to time.Time) (gaps []fodbc.Gap, fills []fodbc.Tick, err error) {
+	reader, ok := sink.(fodbc.TickReader)
+	if !ok {
+		return nil, nil, fmt.Errorf("sink %s cannot read stored prices", *sinkFlag)
+	}
+	ticks, err := reader.ReadTicks(ctx, symbol, interval)
+	if err != nil {
+		return
+	}
+	if len(ticks) == 0 {
+		warn(fmt.Sprintf("No stored bars of %s at %s to check for gaps", symbol, interval))
+		return
+	}
+
+	cal, ok := fodbc.CalendarOf(&ticks[len(ticks)-1])
+	if !ok {
+		warn(fmt.Sprintf("No exchange calendar for %s (%s), skipping gap detection", symbol, ticks[len(ticks)-1].ExchangeName))
+		return
+	}
+
+	gaps, err = fodbc.FindGaps(cal, symbol, interval, ticks, from, to)
+	if err != nil || !*fillFlag {
+		return
+	}
+
+	for _, g := range gaps {
+		req := g.Request()
+		req.IncludePrePost = *prePostFlag
+
+		fetched, err := fodbc.FetchTicks(ctx, provider, req)
+		if err != nil {
+			return gaps, fills, err
+		}
+		if *regularFlag {
+			fetched = fodbc.FilterSessions(fetched, fodbc.SessionRegular)
+		}
+		fills = append(fills, fetched...)
+	}
+	return
+}
@@ -2,112 +2,30 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/piquette/finance-go"
-
 	"github.com/briandowns/spinner"
-	"github.com/piquette/finance-go/chart"
+	"github.com/piquette/finance-go"
 	"github.com/piquette/finance-go/datetime"
-	"github.com/piquette/finance-go/quote"
 
-	"github.com/jakoblorz/dynsql/lib/go-sqlite3"
 	fodbc "github.com/jakoblorz/finance-odbc"
-	"github.com/jmoiron/sqlx"
 )
 
 var (
 	DEBUG = false
+
+	provider fodbc.Provider = fodbc.DefaultProvider
 )
 
 var (
-	cryptoCurrencyQuoteType = strings.ToLower(string(finance.QuoteTypeCryptoPair))
-	equityQuoteType         = strings.ToLower(string(finance.QuoteTypeEquity))
-	etfQuoteType            = strings.ToLower(string(finance.QuoteTypeETF))
-	forexQuoteType          = strings.ToLower(string(finance.QuoteTypeForexPair))
-	futureQuoteType         = strings.ToLower(string(finance.QuoteTypeFuture))
-	indexQuoteType          = strings.ToLower(string(finance.QuoteTypeIndex))
-	mutualfundQuoteType     = strings.ToLower(string(finance.QuoteTypeMutualFund))
-	optionQuoteType         = strings.ToLower(string(finance.QuoteTypeOption))
-
-	quoteTypePadding = map[string]string{
-		cryptoCurrencyQuoteType: "",
-		equityQuoteType:         "        ",
-		etfQuoteType:            "           ",
-		forexQuoteType:          "         ",
-		futureQuoteType:         "        ",
-		indexQuoteType:          "         ",
-		mutualfundQuoteType:     "    ",
-		optionQuoteType:         "        ",
-	}
-
-	quoteTypeTableNameMapping = map[string]string{
-		cryptoCurrencyQuoteType: cryptoCurrencyQuoteType,
-		equityQuoteType:         equityQuoteType,
-		etfQuoteType:            etfQuoteType,
-		forexQuoteType:          forexQuoteType,
-		futureQuoteType:         futureQuoteType,
-		indexQuoteType:          "indices",
-		mutualfundQuoteType:     mutualfundQuoteType,
-		optionQuoteType:         optionQuoteType,
-	}
-
-	quoteTypeControlFuncKeyMapping = map[string]string{
-		cryptoCurrencyQuoteType: cryptoCurrencyQuoteType,
-		equityQuoteType:         equityQuoteType,
-		etfQuoteType:            etfQuoteType,
-		forexQuoteType:          forexQuoteType,
-		futureQuoteType:         futureQuoteType,
-		indexQuoteType:          indexQuoteType,
-		mutualfundQuoteType:     mutualfundQuoteType,
-		optionQuoteType:         optionQuoteType,
-		"ecnquote":              equityQuoteType,
-	}
-
-	quoteTypeControlFuncMapping = map[string][]interface{}{
-		cryptoCurrencyQuoteType: []interface{}{
-			fodbc.NewAnonCryptoFromAPI,
-			fodbc.GetAnonCryptoFromAPI,
-		},
-		equityQuoteType: []interface{}{
-			fodbc.NewAnonEquityFromAPI,
-			fodbc.GetAnonEquityFromAPI,
-		},
-		etfQuoteType: []interface{}{
-			fodbc.NewAnonETFFromAPI,
-			fodbc.GetAnonETFFromAPI,
-		},
-		forexQuoteType: []interface{}{
-			fodbc.NewAnonForexFromAPI,
-			fodbc.GetAnonForexFromAPI,
-		},
-		futureQuoteType: []interface{}{
-			fodbc.NewAnonFutureFromAPI,
-			fodbc.GetAnonFutureFromAPI,
-		},
-		indexQuoteType: []interface{}{
-			fodbc.NewAnonIndexFromAPI,
-			fodbc.GetAnonIndexFromAPI,
-		},
-		mutualfundQuoteType: []interface{}{
-			fodbc.NewAnonMutualFundFromAPI,
-			fodbc.GetAnonMutualFundFromAPI,
-		},
-		optionQuoteType: []interface{}{
-			fodbc.NewAnonOptionFromAPI,
-			fodbc.GetAnonOptionFromAPI,
-		},
-	}
-
-	tickTableName = "ticks"
-
 	tickIntervalPadding = map[string]string{
 		string(datetime.OneMin):      pad("", 1),
 		string(datetime.TwoMins):     pad("", 1),
@@ -121,111 +39,111 @@ var (
 		string(datetime.FiveDay):     pad("", 1),
 		string(datetime.OneMonth):    pad("", 0),
 		string(datetime.ThreeMonth):  pad("", 0),
-		string(datetime.SixMonth):    pad("", 0),
-		string(datetime.OneYear):     pad("", 1),
-		string(datetime.TwoYear):     pad("", 1),
-		string(datetime.FiveYear):    pad("", 1),
-		string(datetime.TenYear):     pad("", 0),
-		string(datetime.YTD):         pad("", 0),
-		string(datetime.Max):         pad("", 0),
 	}
 )
 
+// The flags are bound anew by every run, to the flag set of the global
+// options or of the command they belong to.
 var (
-	cryptoFlags     = []interface{}{cryptoCurrencyQuoteType, flag.String(cryptoCurrencyQuoteType, "", "Download Crypto Information")}
-	equityFlags     = []interface{}{equityQuoteType, flag.String(equityQuoteType, "", "Download Equity Information")}
-	etfFlags        = []interface{}{etfQuoteType, flag.String(etfQuoteType, "", "Download ETF Information")}
-	forexFlags      = []interface{}{forexQuoteType, flag.String(forexQuoteType, "", "Download Forex Information")}
-	futureFlags     = []interface{}{futureQuoteType, flag.String(futureQuoteType, "", "Download Future Information")}
-	stockindexFlags = []interface{}{indexQuoteType, flag.String(indexQuoteType, "", "Download Index Information")}
-	mutualfundFlags = []interface{}{mutualfundQuoteType, flag.String(mutualfundQuoteType, "", "Download Mututal Fund Information")}
-	optionFlags     = []interface{}{optionQuoteType, flag.String(optionQuoteType, "", "Donwload Option Information")}
-
-	allMetaInformationFlags = [][]interface{}{
-		cryptoFlags,
-		equityFlags,
-		etfFlags,
-		forexFlags,
-		futureFlags,
-		stockindexFlags,
-		mutualfundFlags,
-		optionFlags,
-	}
-
-	tickFlag        = flag.String(tickTableName, "", "Download Pricing Information")
-	useAllTicksFlag = flag.Bool("all", false, "Download all possible time intervals")
-
-	oneMinTickIntervalFlags     = []interface{}{string(datetime.OneMin), flag.Bool(string(datetime.OneMin), false, "Use a tick interval of 1min")}
-	twoMinTickIntervalFlags     = []interface{}{string(datetime.TwoMins), flag.Bool(string(datetime.TwoMins), false, "Use a tick interval of 2min")}
-	fiveMinTickIntervalFlags    = []interface{}{string(datetime.FiveMins), flag.Bool(string(datetime.FiveMins), false, "Use a tick interval of 5min")}
-	fifteenMinTickIntervalFlags = []interface{}{string(datetime.FifteenMins), flag.Bool(string(datetime.FifteenMins), false, "Use a tick interval of 15min")}
-	thirtyMinTickIntervalFlags  = []interface{}{string(datetime.ThirtyMins), flag.Bool(string(datetime.ThirtyMins), false, "Use a tick interval of 30min")}
-	sixtyMinTickIntervalFlags   = []interface{}{string(datetime.SixtyMins), flag.Bool(string(datetime.SixtyMins), false, "Use a tick interval of 60min")}
-	ninetyMinTickIntervalFlags  = []interface{}{string(datetime.NinetyMins), flag.Bool(string(datetime.NinetyMins), false, "Use a tick interval of 90min")}
-	oneHourTickIntervalFlags    = []interface{}{string(datetime.OneHour), flag.Bool(string(datetime.OneHour), false, "Use a tick interval of 1h")}
-	oneDayTickIntervalFlags     = []interface{}{string(datetime.OneDay), flag.Bool(string(datetime.OneDay), false, "Use a tick interval of 1d")}
-	fiveDayTickIntervalFlags    = []interface{}{string(datetime.FiveDay), flag.Bool(string(datetime.FiveDay), false, "Use a tick interval of 5d")}
-	oneMonthTickIntervalFlags   = []interface{}{string(datetime.OneMonth), flag.Bool(string(datetime.OneMonth), false, "Use a tick interval of 1mo")}
-	threeMonthTickIntervalFlags = []interface{}{string(datetime.ThreeMonth), flag.Bool(string(datetime.ThreeMonth), false, "Use a tick interval of 3mo")}
-	sixMonthTickIntervalFlags   = []interface{}{string(datetime.SixMonth), flag.Bool(string(datetime.SixMonth), false, "Use a tick interval of 6mo")}
-	oneYearTickIntervalFlags    = []interface{}{string(datetime.OneYear), flag.Bool(string(datetime.OneYear), false, "Use a tick interval of 1y")}
-	twoYearTickIntervalFlags    = []interface{}{string(datetime.TwoYear), flag.Bool(string(datetime.TwoYear), false, "Use a tick interval of 2y")}
-	fiveYearTickIntervalFlags   = []interface{}{string(datetime.FiveYear), flag.Bool(string(datetime.FiveYear), false, "Use a tick interval of 5y")}
-	tenYearTickIntervalFlags    = []interface{}{string(datetime.TenYear), flag.Bool(string(datetime.TenYear), false, "Use a tick interval of 10y")}
-	ytdTickIntervalFlags        = []interface{}{string(datetime.YTD), flag.Bool(string(datetime.YTD), false, "Use a tick interval of YTD (Year-To-Date)")}
-	maxTickIntervalFlags        = []interface{}{string(datetime.Max), flag.Bool(string(datetime.Max), false, "Use the maximum tick interval")}
-
-	allPricingInformationFlags = [][]interface{}{
-		oneMinTickIntervalFlags,
-		twoMinTickIntervalFlags,
-		fiveMinTickIntervalFlags,
-		fifteenMinTickIntervalFlags,
-		thirtyMinTickIntervalFlags,
-		sixtyMinTickIntervalFlags,
-		ninetyMinTickIntervalFlags,
-		oneHourTickIntervalFlags,
-		oneDayTickIntervalFlags,
-		fiveDayTickIntervalFlags,
-		oneMonthTickIntervalFlags,
-		threeMonthTickIntervalFlags,
-		sixMonthTickIntervalFlags,
-		oneYearTickIntervalFlags,
-		twoYearTickIntervalFlags,
-		fiveYearTickIntervalFlags,
-		tenYearTickIntervalFlags,
-		ytdTickIntervalFlags,
-		maxTickIntervalFlags,
-	}
+	configFlag      = new(string)
+	sinkFlag        = new(string)
+	recordFlag      = new(string)
+	replayFlag      = new(string)
+	concurrencyFlag = new(int)
+	rateFlag        = new(float64)
+	retriesFlag     = new(int)
+	batchSizeFlag   = new(int)
+	checkpointFlag  = new(string)
+	reportFlag      = new(string)
+
+	assetFlags = map[string]*string{}
+
+	intervalsFlag   = new(string)
+	useAllTicksFlag = new(bool)
+	fromFlag        = new(string)
+	toFlag          = new(string)
+	incrementalFlag = new(bool)
+	upsertFlag      = new(bool)
+	backfillFlag    = new(bool)
+	prePostFlag     = new(bool)
+	regularFlag     = new(bool)
+	actionsFlag     = new(bool)
+	rangesFlag      = new(bool)
+	fillFlag        = new(bool)
+	intoFlag        = new(string)
+	dryRunFlag      = new(bool)
+	formatFlag      = new(string)
+	outFlag         = new(string)
+	addrFlag        = new(string)
+	watchlistFlag   = new(string)
+	openFlag        = new(bool)
+	daemonFlag      = new(bool)
 )
 
-func parseFlagSetS(fs []interface{}) (tableName string, value string, ok bool) {
-	tableName, ok = fs[0].(string)
-	if !ok {
-		return
-	}
+// command is a subcommand of the CLI. flags, if any, binds its flags to
+// fs with the defaults taken from cfg; run executes it on the opened sink with
+// the arguments left after the flags. The schema of the sink is
+// migrated before commands that write.
+type command struct {
+	name    string
+	summary string
+	writes  bool
+	flags   func(fs *flag.FlagSet, cfg *Config)
+	run     func(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) error
+}
 
-	var valuePtr *string
-	valuePtr, ok = fs[1].(*string)
-	if !ok {
-		return
+var commands = []command{
+	{"quotes", "Download the metadata of the -equity, -index, ... symbols", true, quotesFlags, runQuotes},
+	{"ticks", "Download the historical prices of the given symbols", true, ticksFlags, runTicks},
+	{"gaps", "Report the bars missing from the stored prices of the given symbols", true, gapsFlags, runGaps},
+	{"resample", "Derive coarser bars from the stored prices of the given symbols", true, resampleFlags, runResample},
+	{"migrate", "Bring the schema of the sink up to date", false, migrateFlags, runMigrate},
+	{"export", "Write the stored prices of the given symbols as CSV or JSON lines", false, exportFlags, runExport},
+	{"serve", "Serve the stored prices and corporate actions over HTTP", false, serveFlags, runServe},
+	{"watchlist", "List, show and edit the stored watchlists", true, nil, runWatchlist},
+	{"run", "Collect the watchlists of the config schedule once, or continuously with -daemon", true, runFlags, runRun},
+}
+
+func lookupCommand(name string) (c command, ok bool) {
+	for _, c = range commands {
+		if c.name == name {
+			return c, true
+		}
 	}
-	value = *valuePtr
 	return
 }
 
-func parseFlagSetB(fs []interface{}) (tableName string, value bool, ok bool) {
-	tableName, ok = fs[0].(string)
-	if !ok {
-		return
+func usage(global *flag.FlagSet) func() {
+	return func() {
+		out := global.Output()
+		fmt.Fprintf(out, "Usage: %s [options] <command> [command options] [symbols or watchlists]\n\nCommands:\n", global.Name())
+		for _, c := range commands {
+			fmt.Fprintf(out, "  %-9s %s\n", c.name, c.summary)
+		}
+		fmt.Fprintf(out, "\nOptions:\n")
+		global.PrintDefaults()
+		fmt.Fprintf(out, "\nRun %s <command> -h for the options of a command.\n", global.Name())
 	}
+}
 
-	var valuePtr *bool
-	valuePtr, ok = fs[1].(*bool)
-	if !ok {
-		return
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
 	}
-	value = *valuePtr
-	return
+	return fs.Args(), nil
+}
+
+// splitList splits comma separated values, dropping empty ones.
+func splitList(values ...string) []string {
+	list := []string{}
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
 }
 
 func spin(prefix, final string) func(error) {
@@ -266,13 +184,28 @@ func spin(prefix, final string) func(error) {
 }
 
 var (
-	warnings = []string{}
+	warnings   = []string{}
+	warningsMu sync.Mutex
 )
 
+// warn records msg to be printed once the command is done. It is safe
+// to call from the download workers.
 func warn(msg string) {
+	warningsMu.Lock()
+	defer warningsMu.Unlock()
+
 	warnings = append(warnings, msg)
 	if DEBUG {
-		log.Printf("msg")
+		log.Print(msg)
+	}
+}
+
+func printWarnings() {
+	warningsMu.Lock()
+	defer warningsMu.Unlock()
+
+	for _, w := range warnings {
+		print(fmt.Sprintf("⏩  WARNING: %s\n", w))
 	}
 }
 
@@ -284,186 +217,171 @@ func pad(s string, d int) string {
 }
 
 func fatal(err error) {
-	print(err)
+	print(err.Error())
 	os.Exit(1)
 }
 
 func main() {
-	sqlite3.DefaultTypeMap = map[string]string{
-		"inserted_at": "DATETIME",
-		"timestamp":   "INTEGER",
+	err := run(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal(fmt.Errorf("%s\n", err))
+	}
+}
+
+// run parses the global options, loads the config file and executes
+// the command named by args.
+func run(args []string) error {
+	global := flag.NewFlagSet("finance-odbc", flag.ContinueOnError)
+	global.StringVar(configFlag, "config", "", "Read the defaults of all options from this YAML or TOML file")
+	global.StringVar(sinkFlag, "sink", "", "Storage backend: sqlite://<file>, postgres://<dsn> or file://<dir> (default sqlite://finance.sqlite3)")
+	global.StringVar(recordFlag, "record", "", "Record all API responses into the given cassette directory")
+	global.StringVar(replayFlag, "replay", "", "Serve all API responses from the given cassette directory instead of the network")
+	global.IntVar(concurrencyFlag, "concurrency", 0, "Download this many symbols at once (default 4)")
+	global.Float64Var(rateFlag, "rate", 0, "Make at most this many API requests per second across all downloads, 0 for no limit (default 5)")
+	global.IntVar(retriesFlag, "retries", 0, "Try downloads and writes failing with transient errors this many times (default 4)")
+	global.StringVar(reportFlag, "report", "", "Write what became of every symbol to this JSON file")
+	global.IntVar(batchSizeFlag, "batch-size", 0, fmt.Sprintf("Insert this many rows per statement; every series is written in one transaction (default %d)", fodbc.DefaultBatchSize))
+	global.StringVar(checkpointFlag, "checkpoint", "", "Remember the downloads completed before an interrupt in this file, to skip them when the command is run again (default finance-odbc.checkpoint.json)")
+	global.Usage = usage(global)
+
+	commandLine = append([]string{}, args...)
+	args, err := parseFlags(global, args)
+	if err != nil {
+		return err
+	}
+	outcomes, resumed, interrupted = []outcome{}, nil, 0
+	cfg, err := loadConfig(*configFlag)
+	if err != nil {
+		return err
+	}
+	set := map[string]bool{}
+	global.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["sink"] {
+		*sinkFlag = cfg.Sink
+	}
+	if !set["record"] {
+		*recordFlag = cfg.Record
+	}
+	if !set["replay"] {
+		*replayFlag = cfg.Replay
+	}
+	if !set["concurrency"] {
+		*concurrencyFlag = cfg.Concurrency
+	}
+	if !set["rate"] {
+		*rateFlag = cfg.Rate
+	}
+	if !set["retries"] {
+		*retriesFlag = cfg.Retries
+	}
+	if !set["batch-size"] {
+		*batchSizeFlag = cfg.BatchSize
+	}
+	if !set["checkpoint"] {
+		*checkpointFlag = cfg.Checkpoint
+	}
+	for currency, places := range cfg.Precision {
+		fodbc.SetCurrencyPrecision(currency, places)
 	}
 
-	flag.Parse()
+	if len(args) == 0 {
+		global.Usage()
+		return fmt.Errorf("no command given")
+	}
+	c, ok := lookupCommand(args[0])
+	if !ok {
+		global.Usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	if c.flags != nil {
+		c.flags(fs, cfg)
+	}
+	args, err = parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	finance.SetHTTPClient(fodbc.NewHTTPClient())
+	provider = fodbc.NewRateLimitedProvider(fodbc.DefaultProvider, fodbc.NewTokenBucket(*rateFlag, *concurrencyFlag))
+	if *replayFlag != "" {
+		provider = fodbc.NewReplayProvider(*replayFlag)
+	} else if *recordFlag != "" {
+		provider = fodbc.NewRecordingProvider(provider, *recordFlag)
+	}
+
+	if *checkpointFlag != "" {
+		if resumed, err = loadCheckpoint(*checkpointFlag, commandLine); err != nil {
+			return err
+		}
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	sig := make(chan os.Signal)
+	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
 
+	done := make(chan error, 1)
 	go func() {
-		db, err := sqlx.Connect("dyn-sqlite3", "finance.sqlite3")
-		if err != nil {
-			fatal(err)
-		}
-		defer db.Close()
-
-		didDownloadMetaInformation := false
-	ITERATE_METAINFORMATION_SOURCES:
-		for _, f := range allMetaInformationFlags {
-			quoteType, value, ok := parseFlagSetS(f)
-			if !ok || value == "" {
-				continue
-			}
-
-			tableName := quoteTypeTableNameMapping[quoteType]
-			values := strings.Split(value, ",")
-			cancel := spin(
-				fmt.Sprintf("Downloading Metadata for %s:%s %d Download(s) required ", quoteType, quoteTypePadding[quoteType], len(values)),
-				"",
-			)
-
-			for _, value := range values {
-
-				q, err := quote.Get(value)
-				if err != nil {
-					cancel(err)
-					continue ITERATE_METAINFORMATION_SOURCES
-				}
-				if q == nil {
-					warnings = append(warnings, fmt.Sprintf("Parsing of response failed, skipping %s", value))
-					continue
-				}
-
-				actualQuoteType := strings.ToLower(string(q.QuoteType))
-				actualTableName, ok := quoteTypeTableNameMapping[actualQuoteType]
-				if !ok {
-					warnings = append(warnings, fmt.Sprintf("Found unregistered quote type %s, will create rogue table to accomodate symbol %s", actualQuoteType, value))
-					actualTableName = actualQuoteType
-				}
-				controlFuncKey, ok := quoteTypeControlFuncKeyMapping[actualQuoteType]
-				if !ok {
-					warnings = append(warnings, fmt.Sprintf("Could not find parsing methods for quote type %s, skipping %s", actualQuoteType, value))
-				}
-
-				controlFuncs := quoteTypeControlFuncMapping[controlFuncKey]
-				assetConvertAPI := controlFuncs[0].(func(interface{}) (interface{}, bool))
-				assetObtainAPI := controlFuncs[1].(func(string) (interface{}, error))
-
-				retrieved, err := assetObtainAPI(value)
-				if err != nil {
-					cancel(err)
-					continue ITERATE_METAINFORMATION_SOURCES
-				}
-				asset, ok := assetConvertAPI(retrieved)
-				if !ok {
-					warnings = append(warnings, fmt.Sprintf("Parsing of response failed, skipping %s", value))
-					continue
-				}
-
-				if actualTableName != tableName {
-					warnings = append(warnings, fmt.Sprintf("Writing %s into table %s instead of %s", value, actualTableName, tableName))
-				}
-
-				data, err := json.Marshal(asset)
-				if err != nil {
-					cancel(err)
-					continue ITERATE_METAINFORMATION_SOURCES
-				}
-
-				_, err = db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s JSON %s;", actualTableName, string(data)))
-				if err != nil {
-					cancel(err)
-					continue ITERATE_METAINFORMATION_SOURCES
-				}
-			}
-			cancel(nil)
-
-			didDownloadMetaInformation = true
-		}
+		done <- execute(ctx, c, cfg, args)
+	}()
 
-		didDownloadPricingInformation := false
-		if *tickFlag != "" {
-			values := strings.Split(*tickFlag, ",")
-			tUTCNow := time.Now().UTC()
-			duplicates := map[string]int{}
-
-		ITERATE_PRICING_INTERVALS:
-			for _, f := range allPricingInformationFlags {
-				interval, doDownload, ok := parseFlagSetB(f)
-				if !(*useAllTicksFlag) && (!ok || !doDownload) {
-					continue
-				}
-
-				cancel := spin(
-					fmt.Sprintf("Downloading Historical Prices with an interval of %s:%s %d Download(s) required ", interval, tickIntervalPadding[interval], len(values)),
-					"",
-				)
-				for _, value := range values {
-
-					iter := chart.Get(&chart.Params{
-						Symbol:   value,
-						End:      datetime.New(&tUTCNow),
-						Start:    datetime.FromUnix(int(tUTCNow.Unix() - 100*60*60)),
-						Interval: datetime.Interval(interval),
-					})
-
-					ticks := []string{}
-					for iter.Next() {
-						tick := fodbc.NewTickFromAPI(&fodbc.MetaTick{
-							ChartBar:  *iter.Bar(),
-							ChartMeta: iter.Meta(),
-						})
-
-						for _, tick := range tick.PermutateGranularity() {
-							data, err := json.Marshal(tick)
-							if err != nil {
-								cancel(err)
-								continue ITERATE_PRICING_INTERVALS
-							}
-
-							if _, ok := duplicates[string(data)]; ok {
-								continue
-							}
-
-							ticks = append(ticks, string(data))
-							duplicates[string(data)] = 1
-						}
-					}
-					if err := iter.Err(); err != nil {
-						cancel(err)
-						continue ITERATE_PRICING_INTERVALS
-					}
-
-					for _, tick := range ticks {
-						_, err = db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s JSON %s;", tickTableName, tick))
-						if err != nil {
-							cancel(err)
-							continue ITERATE_PRICING_INTERVALS
-						}
-					}
-
-				}
-				cancel(nil)
-			}
+	// The first interrupt stops the downloads and lets the command write
+	// what it already has; the second one quits right away.
+	select {
+	case <-sig:
+		print("\nInterrupted, writing what was downloaded; interrupt again to quit right away\n")
+		cancel()
+	case err := <-done:
+		return err
+	}
+	select {
+	case <-sig:
+		return fmt.Errorf("interrupted twice, quitting without writing what was downloaded")
+	case err := <-done:
+		return err
+	}
+}
 
-			didDownloadPricingInformation = len(values) != 0
-		}
+func execute(ctx context.Context, c command, cfg *Config, args []string) error {
+	sink, err := openSink(ctx, *sinkFlag)
+	if err != nil {
+		return err
+	}
+	if b, ok := sink.(fodbc.BatchSizer); ok {
+		b.SetBatchSize(*batchSizeFlag)
+	}
 
-		// print warnings
-		if len(warnings) > 0 {
-			for _, w := range warnings {
-				print(fmt.Sprintf("⏩  WARNING: %s\n", w))
-			}
-		}
+	if _, ok := sink.(fodbc.Migrator); ok && c.writes {
+		_, _, err = migrateSink(ctx, sink, false, false)
+	}
+	if err == nil {
+		err = c.run(ctx, sink, cfg, args)
+	}
 
-		if !didDownloadMetaInformation && !didDownloadPricingInformation {
-			flag.PrintDefaults()
+	// Sinks may hold back rows until they are flushed, so a failing flush
+	// or close loses the run. An interrupt cancels ctx, but what was
+	// downloaded is still written.
+	serr := sink.Flush(context.Background())
+	if cerr := sink.Close(); serr == nil {
+		serr = cerr
+	}
+	if serr != nil {
+		if err != nil {
+			warn(fmt.Sprintf("Writing to the sink failed: %s", serr))
+		} else {
+			err = fmt.Errorf("writing to the sink failed: %s", serr)
 		}
+	}
 
-		sig <- os.Interrupt
-	}()
-
-	<-sig
+	printWarnings()
+	if rerr := report(); err == nil {
+		err = rerr
+	}
+	return err
 }
@@ -1,27 +1,299 @@
 package main
 
-import "testing"
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+	"github.com/jmoiron/sqlx"
+)
+
+func countLines(t *testing.T, path string) int {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	n := 0
+	for s := bufio.NewScanner(f); s.Scan(); {
+		n++
+	}
+	return n
+}
 
 func Test(t *testing.T) {
 
 	DEBUG = true
 
-	cryptoAssets := "GDAXI,acb,exf"
-	cryptoFlags[1] = &cryptoAssets
+	out := t.TempDir()
+	global := []string{"-sink", "file://" + out, "-replay", "testdata/cassette"}
+	quotes := append(global, "quotes", "-equity", "AAPL", "-index", "^GDAXI")
+	ticks := append(global, "ticks", "-intervals", "1d", "-actions", "AAPL")
+
+	for _, args := range [][]string{quotes, ticks} {
+		if err := run(args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(warnings) > 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	if n := countLines(t, filepath.Join(out, "equity.jsonl")); n != 1 {
+		t.Errorf("wrote %d equities, want 1", n)
+	}
+	if n := countLines(t, filepath.Join(out, "indices.jsonl")); n != 1 {
+		t.Errorf("wrote %d indices, want 1", n)
+	}
+	n := countLines(t, filepath.Join(out, "ticks.jsonl"))
+	if n == 0 {
+		t.Errorf("wrote no ticks")
+	}
+
+	if err := run(ticks); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := countLines(t, filepath.Join(out, "ticks.jsonl")); m != n {
+		t.Errorf("second run wrote %d ticks again", m-n)
+	}
+	if n := countLines(t, filepath.Join(out, "corporate_actions.jsonl")); n != 1 {
+		t.Errorf("wrote %d corporate actions, want 1", n)
+	}
+}
+
+func TestRun(t *testing.T) {
+
+	DEBUG = true
+
+	out := t.TempDir()
+	config := writeConfig(t, "finance.yaml", `
+watchlists:
+  tech: [AAPL]
+ticks:
+  intervals: [1d]
+schedule:
+  - watchlist: tech
+    quotes: "* 9-15 * * 1-5"
+    ticks: "CRON_TZ=America/New_York 30 17 * * 1-5"
+`)
+	global := []string{"-config", config, "-sink", "file://" + out, "-replay", "testdata/cassette"}
+
+	// The market of AAPL is closed in the cassette.
+	if err := run(append(global, "run")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "equity.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("stored the quote of a closed market")
+	}
+	if n := countLines(t, filepath.Join(out, "ticks.jsonl")); n == 0 {
+		t.Errorf("wrote no ticks")
+	}
+
+	if err := run(append(global, "run", "-open=false")); err != nil {
+		t.Fatal(err)
+	}
+	if n := countLines(t, filepath.Join(out, "equity.jsonl")); n != 1 {
+		t.Errorf("wrote %d equities, want 1", n)
+	}
+
+	if err := run(append(global, "run", "AAPL")); err == nil {
+		t.Errorf("run accepted symbols")
+	}
+}
+
+func TestMigrateFails(t *testing.T) {
+	// A view cannot be altered, so the migration of ticks fails.
+	path := filepath.Join(t.TempDir(), "finance.sqlite3")
+	db, err := sqlx.Connect("dyn-sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.MustExec(`CREATE VIEW "ticks" AS SELECT 'AAPL' AS "symbol"`)
+	db.Close()
+
+	if err := run([]string{"-sink", "sqlite://" + path, "migrate"}); err == nil {
+		t.Errorf("failed migration succeeded")
+	}
+}
 
-	equityAssets := "APPL,AAPL"
-	equityFlags[1] = &equityAssets
+func TestRunStoredWatchlist(t *testing.T) {
+
+	DEBUG = true
+
+	path := filepath.Join(t.TempDir(), "finance.sqlite3")
+	sink := "sqlite://" + path
+	config := writeConfig(t, "finance.yaml", `
+ticks:
+  intervals: [1d]
+schedule:
+  - watchlist: tech
+    ticks: "30 22 * * 1-5"
+`)
+	global := []string{"-config", config, "-sink", sink, "-replay", "testdata/cassette"}
+
+	if err := run(append(global, "run")); err == nil {
+		t.Errorf("ran the schedule of an unknown watchlist")
+	}
+	if err := run(append(global, "watchlist", "add", "tech", "AAPL")); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(append(global, "run")); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sqlx.Connect("dyn-sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var n int
+	if err := db.Get(&n, `SELECT COUNT(*) FROM "ticks" WHERE "symbol" = 'AAPL'`); err != nil || n == 0 {
+		t.Errorf("wrote no ticks of the stored watchlist, %v", err)
+	}
+}
+
+func TestOpenSink(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	for rawurl, want := range map[string]func(fodbc.Sink) bool{
+		"sqlite://" + filepath.Join(dir, "finance.sqlite3"): func(s fodbc.Sink) bool { _, ok := s.(*fodbc.SQLiteSink); return ok },
+		"file://" + filepath.Join(dir, "out"):               func(s fodbc.Sink) bool { _, ok := s.(*fodbc.FileSink); return ok },
+	} {
+		sink, err := openSink(ctx, rawurl)
+		if err != nil {
+			t.Fatalf("%s: %s", rawurl, err)
+		}
+		if !want(sink) {
+			t.Errorf("%s opened a %T", rawurl, sink)
+		}
+		sink.Close()
+	}
+	for _, rawurl := range []string{"mysql://localhost/finance", "finance.sqlite3", "%"} {
+		if _, err := openSink(ctx, rawurl); err == nil {
+			t.Errorf("opened unsupported sink %q", rawurl)
+		}
+	}
+
+	// The quotes land in the database the flag selects.
+	path := filepath.Join(dir, "quotes.sqlite3")
+	if err := run([]string{"-sink", "sqlite://" + path, "-replay", "testdata/cassette", "quotes", "-equity", "AAPL"}); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sqlx.Connect("dyn-sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var n int
+	if err := db.Get(&n, `SELECT COUNT(*) FROM "equity"`); err != nil || n != 1 {
+		t.Errorf("stored %d equities, want 1, %v", n, err)
+	}
+}
+
+func TestSinkWriteFails(t *testing.T) {
+	// The ticks of a file sink are written when it is flushed, which
+	// fails while their temporary file is taken.
+	out := t.TempDir()
+	if err := os.Mkdir(filepath.Join(out, "ticks.jsonl.tmp"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"-sink", "file://" + out, "-replay", "testdata/cassette", "ticks", "-intervals", "1d", "AAPL"}
+	if err := run(args); err == nil {
+		t.Errorf("lost the ticks without failing")
+	}
+}
+
+func TestRunRoundFlushes(t *testing.T) {
+
+	DEBUG = true
+
+	config := writeConfig(t, "finance.yaml", `
+watchlists:
+  tech: [AAPL]
+ticks:
+  intervals: [1d]
+schedule:
+  - watchlist: tech
+    ticks: "30 22 * * 1-5"
+`)
+	// A single run sets the flags a round of the daemon uses.
+	if err := run([]string{"-config", config, "-sink", "file://" + t.TempDir(), "-replay", "testdata/cassette", "run"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, taken := range []bool{false, true} {
+		out := t.TempDir()
+		if taken {
+			if err := os.Mkdir(filepath.Join(out, "ticks.jsonl.tmp"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		sink, err := openSink(ctx, "file://"+out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sink.Close()
+
+		provider = fodbc.NewReplayProvider("testdata/cassette")
+		jobs, err := scheduledJobs(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = runRound(ctx, sink, cfg, jobs, time.Now())
+		if taken {
+			if err == nil {
+				t.Errorf("round succeeded without writing its ticks")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := countLines(t, filepath.Join(out, "ticks.jsonl")); n == 0 {
+			t.Errorf("round left its ticks unwritten")
+		}
+	}
+}
 
-	indexAssets := "^GDAXI"
-	stockindexFlags[1] = &indexAssets
+func TestConfigPrecision(t *testing.T) {
+	t.Cleanup(func() { fodbc.SetCurrencyPrecision("USD", fodbc.DefaultPricePrecision) })
 
-	tickAssets := "AAPL"
-	tickFlag = &tickAssets
+	config := writeConfig(t, "finance.yaml", "precision:\n  USD: 0\n")
+	out := t.TempDir()
+	if err := run([]string{"-config", config, "-sink", "file://" + out, "-replay", "testdata/cassette", "ticks", "-intervals", "1d", "AAPL"}); err != nil {
+		t.Fatal(err)
+	}
 
-	enableFlag := true
-	oneDayTickIntervalFlags[1] = &enableFlag
-	oneMonthTickIntervalFlags[1] = &enableFlag
+	f, err := os.Open(filepath.Join(out, "ticks.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
 
-	main()
-	t.FailNow()
+	n := 0
+	for s := bufio.NewScanner(f); s.Scan(); n++ {
+		tick := fodbc.Tick{}
+		if err := json.Unmarshal(s.Bytes(), &tick); err != nil {
+			t.Fatal(err)
+		}
+		if !tick.Close.Equal(tick.Close.Round(0)) {
+			t.Fatalf("close %s of %s is not rounded to whole dollars", tick.Close, tick.Timestamp)
+		}
+	}
+	if n == 0 {
+		t.Fatal("wrote no ticks")
+	}
 }
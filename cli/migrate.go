@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+)
+
+func migrateFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.BoolVar(dryRunFlag, "dry-run", false, "Only list the migrations that would be applied")
+}
+
+// runMigrate migrates the schema of the sink and lists what was done.
+func runMigrate(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) error {
+	cancel := spin(fmt.Sprintf("Migrating Schema of %s ", *sinkFlag), "")
+	ms, version, err := migrateSink(ctx, sink, *dryRunFlag, true)
+	cancel(err)
+	if err != nil {
+		return err
+	}
+
+	verb := "Applied"
+	if *dryRunFlag {
+		verb = "Pending"
+	}
+	print(fmt.Sprintf("%s Migrations: %d, Schema Version: %d\n", verb, len(ms), version))
+	for _, m := range ms {
+		print(fmt.Sprintf("  %s\n", m))
+		for _, d := range m.Deletes {
+			print(fmt.Sprintf("    drops %s\n", d))
+		}
+	}
+	return nil
+}
+
+// migrateSink brings the tables of sink in line with the structs stored
+// in them and returns the applied migrations with the resulting schema
+// version. With dryRun the migrations are only planned. Unless
+// explicit is set, nothing is migrated if a migration would delete
+// rows or rewrite stored values.
+func migrateSink(ctx context.Context, sink fodbc.Sink, dryRun, explicit bool) (ms []fodbc.Migration, version int, err error) {
+	m, ok := sink.(fodbc.Migrator)
+	if !ok {
+		return nil, 0, fmt.Errorf("sink %s has no schema to migrate", *sinkFlag)
+	}
+
+	ms, err = m.PlanMigrations(ctx)
+	if err != nil {
+		return
+	}
+	if dryRun {
+		version, err = m.SchemaVersion(ctx)
+		return
+	}
+	if !explicit {
+		if err = fodbc.CheckAdditive(ms); err != nil {
+			return
+		}
+	}
+	version, err = m.Migrate(ctx, ms)
+	return
+}
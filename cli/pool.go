@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+)
+
+// fetchAll runs fetch for every symbol on up to -concurrency goroutines.
+// The write functions fetch returns are called one at a time on the
+// calling goroutine, so the sink only ever sees a single writer. Both
+// are retried on transient errors; what became of every symbol is
+// recorded as an outcome of step, and a symbol that fails does not keep
+// the others from being processed. Symbols completed by the run being
+// resumed are skipped. Once ctx is cancelled no more symbols are
+// fetched, but everything fetched until then is still written.
+func fetchAll(ctx context.Context, step string, symbols []string, fetch func(ctx context.Context, symbol string) (write func(ctx context.Context) error, err error)) error {
+	policy := fodbc.DefaultRetryPolicy
+	policy.Attempts = *retriesFlag
+
+	pending := []string{}
+	for _, symbol := range symbols {
+		if !resumed.completed(step, symbol) {
+			pending = append(pending, symbol)
+		}
+	}
+
+	type result struct {
+		symbol   string
+		write    func(ctx context.Context) error
+		attempts int
+		err      error
+	}
+	queue := make(chan string)
+	results := make(chan result)
+
+	workers := *concurrencyFlag
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range queue {
+				r := result{symbol: symbol}
+				r.attempts, r.err = policy.Do(ctx, func() (err error) {
+					r.write, err = fetch(ctx, symbol)
+					return
+				})
+				results <- r
+			}
+		}()
+	}
+
+	go func() {
+		defer close(queue)
+		for _, symbol := range pending {
+			select {
+			case queue <- symbol:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	failed, processed := 0, 0
+	for r := range results {
+		if r.err != nil && ctx.Err() != nil {
+			continue
+		}
+		if r.err == nil && r.write != nil {
+			attempts := 0
+			attempts, r.err = policy.Do(detached{ctx}, func() error {
+				return r.write(detached{ctx})
+			})
+			if attempts > r.attempts {
+				r.attempts = attempts
+			}
+		}
+		if r.err != nil {
+			failed++
+		}
+		processed++
+		record(step, r.symbol, r.attempts, r.err)
+	}
+
+	if left := len(pending) - processed; left > 0 {
+		interrupted += left
+		return fmt.Errorf("interrupted, %d of %d symbols left", left, len(symbols))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d symbols failed", failed, len(symbols))
+	}
+	return nil
+}
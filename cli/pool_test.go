@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFetchAll(t *testing.T) {
+	*concurrencyFlag, *retriesFlag = 3, 2
+	outcomes = []outcome{}
+	symbols := []string{}
+	for i := 0; i < 20; i++ {
+		symbols = append(symbols, fmt.Sprintf("S%d", i))
+	}
+
+	var running, maxRunning int32
+	written := map[string]bool{}
+	err := fetchAll(context.Background(), "1d", symbols, func(ctx context.Context, symbol string) (func(context.Context) error, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		return func(ctx context.Context) error {
+			written[symbol] = true
+			return nil
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != len(symbols) {
+		t.Errorf("wrote %d symbols, want %d", len(written), len(symbols))
+	}
+	if maxRunning > 3 {
+		t.Errorf("%d fetches ran at once, want at most 3", maxRunning)
+	}
+
+	outcomes = []outcome{}
+	tries := map[string]int{}
+	err = fetchAll(context.Background(), "1d", symbols, func(ctx context.Context, symbol string) (func(context.Context) error, error) {
+		switch symbol {
+		case "S1":
+			return nil, errors.New("unknown symbol")
+		case "S2":
+			return func(ctx context.Context) error {
+				tries[symbol]++
+				if tries[symbol] == 1 {
+					return errors.New("database is locked")
+				}
+				return nil
+			}, nil
+		}
+		return nil, nil
+	})
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+	if len(outcomes) != len(symbols) {
+		t.Fatalf("recorded %d outcomes, want %d", len(outcomes), len(symbols))
+	}
+	failed := failedOutcomes()
+	if len(failed) != 1 || failed[0].Symbol != "S1" || failed[0].Attempts != 1 {
+		t.Errorf("unexpected failures %+v", failed)
+	}
+	if tries["S2"] != 2 {
+		t.Errorf("wrote S2 %d times, want 2", tries["S2"])
+	}
+}
+
+func TestFetchAllInterrupted(t *testing.T) {
+	*concurrencyFlag, *retriesFlag = 1, 1
+	outcomes, interrupted = []outcome{}, 0
+	resumed = &checkpoint{Completed: map[string][]string{"1d": {"S0"}}}
+	defer func() { resumed = nil }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := fetchAll(ctx, "1d", []string{"S0", "S1", "S2", "S3"}, func(ctx context.Context, symbol string) (func(context.Context) error, error) {
+		switch symbol {
+		case "S0":
+			t.Errorf("fetched S0 again")
+		case "S1":
+			// The interrupt arrives while S1 is downloaded; it is
+			// written all the same.
+			cancel()
+		default:
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		return func(ctx context.Context) error { return ctx.Err() }, nil
+	})
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+	if len(outcomes) != 1 || outcomes[0].Symbol != "S1" || outcomes[0].Error != "" {
+		t.Errorf("unexpected outcomes %+v", outcomes)
+	}
+	if interrupted != 2 {
+		t.Errorf("%d symbols interrupted, want 2", interrupted)
+	}
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	commandLine := []string{"ticks", "-intervals", "1d"}
+	if err := saveCheckpoint(path, commandLine); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadCheckpoint(path, commandLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.completed("1d", "S0") || !c.completed("1d", "S1") || c.completed("1d", "S2") {
+		t.Errorf("unexpected checkpoint %+v", c.Completed)
+	}
+	if c, _ := loadCheckpoint(path, []string{"ticks", "-intervals", "1h"}); c != nil {
+		t.Errorf("resumed the checkpoint of another command line")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+)
+
+func quotesFlags(fs *flag.FlagSet, cfg *Config) {
+	for _, class := range fodbc.AssetClasses() {
+		assetFlags[class.Name] = fs.String(class.Name, strings.Join(cfg.Quotes[class.Name], ","), fmt.Sprintf("Download %s Information", class.Title))
+	}
+	fs.StringVar(watchlistFlag, "watchlist", "", "Also download the symbols of these comma separated watchlists: stored ones, ones from the config or .txt and .csv files")
+	fs.BoolVar(openFlag, "open", false, "Skip the symbols whose market is closed")
+}
+
+func assetPadding(name string) string {
+	width := 0
+	for _, class := range fodbc.AssetClasses() {
+		if len(class.Name) > width {
+			width = len(class.Name)
+		}
+	}
+	return pad("", width-len(name))
+}
+
+// runQuotes downloads the metadata of the symbols given per asset class
+// and stores it in the table of the class the symbols turn out to have.
+// Watchlist members without an asset class go wherever they turn out to
+// belong. With -open, symbols whose market is closed are skipped.
+func runQuotes(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("give the symbols %s with their asset class: %s", strings.Join(args, " "), strings.Join(append(quoteFlagNames(), "-watchlist"), ", "))
+	}
+
+	requested := map[string][]string{}
+	for _, class := range fodbc.AssetClasses() {
+		if valuePtr, ok := assetFlags[class.Name]; ok {
+			requested[class.Name] = cfg.expand(splitList(*valuePtr))
+		}
+	}
+	members, err := loadWatchlists(ctx, sink, cfg, *watchlistFlag)
+	if err != nil {
+		return err
+	}
+	unclassed := []string{}
+	for _, m := range members {
+		if m.Class == "" {
+			unclassed = append(unclassed, m.Symbol)
+			continue
+		}
+		requested[m.Class] = append(requested[m.Class], m.Symbol)
+	}
+
+	didDownloadMetaInformation, closed := false, 0
+	for _, class := range append(fodbc.AssetClasses(), fodbc.AssetClass{Name: "watchlist"}) {
+		values := requested[class.Name]
+		if class.TableName == "" {
+			values = unclassed
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		cancel := spin(
+			fmt.Sprintf("Downloading Metadata for %s:%s %d Download(s) required ", class.Name, assetPadding(class.Name), len(values)),
+			"",
+		)
+
+		cancel(fetchAll(ctx, class.Name, values, func(ctx context.Context, value string) (func(context.Context) error, error) {
+			q, err := provider.GetQuote(ctx, value)
+			if err != nil {
+				return nil, err
+			}
+			if q == nil {
+				return nil, fmt.Errorf("parsing of response failed")
+			}
+			if *openFlag && !fodbc.MarketOpen(q) {
+				return func(context.Context) error {
+					closed++
+					return nil
+				}, nil
+			}
+
+			actualQuoteType := strings.ToLower(string(q.QuoteType))
+			actualClass, ok := fodbc.LookupAssetClass(actualQuoteType)
+			if !ok {
+				return nil, fmt.Errorf("could not find parsing methods for quote type %s", actualQuoteType)
+			}
+
+			retrieved, err := actualClass.Get(ctx, provider, value)
+			if err != nil {
+				return nil, err
+			}
+			asset, ok := actualClass.Convert(retrieved)
+			if !ok {
+				return nil, fmt.Errorf("parsing of response failed")
+			}
+
+			if class.TableName != "" && actualClass.TableName != class.TableName {
+				warn(fmt.Sprintf("Writing %s into table %s instead of %s", value, actualClass.TableName, class.TableName))
+			}
+
+			return func(ctx context.Context) error {
+				return sink.WriteQuotes(ctx, actualClass.TableName, []interface{}{asset})
+			}, nil
+		}))
+
+		didDownloadMetaInformation = true
+	}
+
+	if !didDownloadMetaInformation {
+		return fmt.Errorf("no symbols given, use %s", strings.Join(append(quoteFlagNames(), "-watchlist"), ", "))
+	}
+	if closed > 0 {
+		print(fmt.Sprintf("Quotes: skipped %d symbol(s) of closed markets\n", closed))
+	}
+	return nil
+}
+
+func quoteFlagNames() []string {
+	names := []string{}
+	for _, class := range fodbc.AssetClasses() {
+		names = append(names, "-"+class.Name)
+	}
+	return names
+}
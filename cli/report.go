@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// outcome is what became of a symbol in one step of a command, e.g. the
+// 1d prices or the corporate actions of AAPL.
+type outcome struct {
+	Step     string `json:"step"`
+	Symbol   string `json:"symbol"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+var (
+	outcomes = []outcome{}
+)
+
+// record is only called by the writer of fetchAll, never by the
+// download workers.
+func record(step, symbol string, attempts int, err error) {
+	o := outcome{Step: step, Symbol: symbol, Attempts: attempts}
+	if err != nil {
+		o.Error = err.Error()
+	}
+	outcomes = append(outcomes, o)
+}
+
+func failedOutcomes() []outcome {
+	failed := []outcome{}
+	for _, o := range outcomes {
+		if o.Error != "" {
+			failed = append(failed, o)
+		}
+	}
+	return failed
+}
+
+// report prints the failed symbols and writes all outcomes to -report,
+// if given. It fails if any symbol did or the run was interrupted, in
+// which case the completed downloads are saved to -checkpoint.
+func report() error {
+	if *reportFlag != "" {
+		data, err := json.MarshalIndent(outcomes, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(*reportFlag, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+	if len(outcomes) == 0 && interrupted == 0 {
+		return nil
+	}
+
+	failed := failedOutcomes()
+	print(fmt.Sprintf("Downloads: %d succeeded, %d failed, %d interrupted\n", len(outcomes)-len(failed), len(failed), interrupted))
+	for _, o := range failed {
+		print(fmt.Sprintf("  %s (%s, %d attempt(s)): %s\n", o.Symbol, o.Step, o.Attempts, o.Error))
+	}
+
+	if interrupted > 0 && *checkpointFlag != "" {
+		if err := saveCheckpoint(*checkpointFlag, commandLine); err != nil {
+			return err
+		}
+		return fmt.Errorf("interrupted with %d downloads left, run the same command again to resume from %s", interrupted, *checkpointFlag)
+	}
+	if interrupted > 0 {
+		return fmt.Errorf("interrupted with %d downloads left", interrupted)
+	}
+	if resumed != nil {
+		if err := os.Remove(*checkpointFlag); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d downloads failed", len(failed), len(outcomes))
+	}
+	return nil
+}
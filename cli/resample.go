@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+)
+
+func resampleFlags(fs *flag.FlagSet, cfg *Config) {
+	priceFlags(fs, cfg, cfg.Ticks.From)
+	fs.StringVar(intoFlag, "into", "", "Comma separated granularities to derive from the -intervals bars, e.g. 4h,1wk")
+}
+
+// runResample derives the -into granularities from the stored prices of
+// the given symbols at every selected interval. A symbol failing to
+// resample does not keep the others from being resampled.
+func runResample(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) error {
+	batches, _, _, err := selectPrices(ctx, sink, cfg, args)
+	if err != nil {
+		return err
+	}
+	resampleTo := splitList(*intoFlag)
+	if len(resampleTo) == 0 {
+		return fmt.Errorf("no granularities given, use -into")
+	}
+
+	tickStats := fodbc.WriteStats{}
+	for _, batch := range batches {
+		interval, values := batch.interval, batch.symbols
+		cancel := spin(
+			fmt.Sprintf("Resampling Historical Prices with an interval of %s:%s into %s ", interval, tickIntervalPadding[interval], strings.Join(resampleTo, ",")),
+			"",
+		)
+		cancel(fetchAll(ctx, interval, values, func(ctx context.Context, value string) (func(context.Context) error, error) {
+			bars, err := resampleTicks(ctx, sink, value, interval, resampleTo)
+			if err != nil || len(bars) == 0 {
+				return nil, err
+			}
+			return func(ctx context.Context) error {
+				stats, err := sink.WriteTicks(ctx, bars, fodbc.UpsertResampled)
+				tickStats.Add(stats)
+				return err
+			}, nil
+		}))
+	}
+
+	print(fmt.Sprintf("Ticks: %s\n", tickStats))
+	return nil
+}
+
+// resampleTicks derives bars of every granularity in targets from all
+// stored bars of symbol at interval. The derived bars are to replace
+// the ones resampled before, since they follow from the source bars,
+// but never bars downloaded at the target granularity.
+func resampleTicks(ctx context.Context, sink fodbc.Sink, symbol, interval string, targets []string) ([]fodbc.Tick, error) {
+	reader, ok := sink.(fodbc.TickReader)
+	if !ok {
+		return nil, fmt.Errorf("sink %s cannot read stored prices", *sinkFlag)
+	}
+	ticks, err := reader.ReadTicks(ctx, symbol, interval)
+	if err != nil {
+		return nil, err
+	}
+	if *regularFlag {
+		ticks = fodbc.FilterSessions(ticks, fodbc.SessionRegular)
+	}
+	if len(ticks) == 0 {
+		warn(fmt.Sprintf("No stored bars of %s at %s to resample", symbol, interval))
+		return nil, nil
+	}
+
+	resampled := []fodbc.Tick{}
+	for _, target := range targets {
+		bars, err := fodbc.Resample(ticks, target)
+		if err != nil {
+			return nil, err
+		}
+		resampled = append(resampled, bars...)
+	}
+	return resampled, nil
+}
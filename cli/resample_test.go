@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+	"github.com/shopspring/decimal"
+)
+
+// tickStore serves the daily bars of AAPL and fails to read those of
+// any other symbol.
+type tickStore struct {
+	fodbc.Sink
+
+	written []fodbc.Tick
+}
+
+func (s *tickStore) ReadTicks(ctx context.Context, symbol, granularity string) ([]fodbc.Tick, error) {
+	if symbol != "AAPL" {
+		return nil, errors.New("disk I/O error")
+	}
+	ticks := []fodbc.Tick{}
+	for day := 1; day <= 3; day++ {
+		ticks = append(ticks, fodbc.Tick{
+			Symbol:      symbol,
+			Granularity: granularity,
+			Timestamp:   fodbc.Timestamp{Time: time.Date(2023, 3, day, 0, 0, 0, 0, time.UTC)},
+			Close:       decimal.New(int64(day), 0),
+		})
+	}
+	return ticks, nil
+}
+
+func (s *tickStore) WriteTicks(ctx context.Context, ticks []fodbc.Tick, mode fodbc.WriteMode) (fodbc.WriteStats, error) {
+	s.written = append(s.written, ticks...)
+	return fodbc.WriteStats{Inserted: len(ticks)}, nil
+}
+
+func TestResampleReportsFailures(t *testing.T) {
+	*concurrencyFlag, *retriesFlag = 2, 1
+	*intervalsFlag, *useAllTicksFlag, *watchlistFlag, *regularFlag = "1d", false, "", false
+	*fromFlag, *toFlag, *intoFlag = "max", "now", "1mo"
+	outcomes, resumed, interrupted = []outcome{}, nil, 0
+
+	sink := &tickStore{}
+	if err := runResample(context.Background(), sink, defaultConfig(), []string{"BAD", "AAPL"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.written) != 1 || !sink.written[0].Close.Equal(decimal.New(3, 0)) {
+		t.Errorf("wrote %+v, want the March bar of AAPL", sink.written)
+	}
+	failed := failedOutcomes()
+	if len(outcomes) != 2 || len(failed) != 1 || failed[0].Symbol != "BAD" {
+		t.Errorf("recorded %+v, want BAD to fail", outcomes)
+	}
+	if err := report(); err == nil {
+		t.Errorf("report passed despite the failure")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+)
+
+func serveFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(addrFlag, "addr", "localhost:8080", "Listen on this address")
+}
+
+// runServe answers
+//
+//	GET /ticks?symbol=AAPL&interval=1d    stored bars, oldest first
+//	GET /actions?symbol=AAPL              stored dividends and splits
+//
+// with JSON until the context is cancelled.
+func runServe(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ticks", func(w http.ResponseWriter, r *http.Request) {
+		reader, ok := sink.(fodbc.TickReader)
+		if !ok {
+			http.Error(w, fmt.Sprintf("sink %s cannot read stored prices", *sinkFlag), http.StatusNotImplemented)
+			return
+		}
+		symbol, interval := r.URL.Query().Get("symbol"), r.URL.Query().Get("interval")
+		if symbol == "" || interval == "" {
+			http.Error(w, "symbol and interval are required", http.StatusBadRequest)
+			return
+		}
+
+		ticks, err := reader.ReadTicks(r.Context(), symbol, interval)
+		respond(w, ticks, err)
+	})
+	mux.HandleFunc("/actions", func(w http.ResponseWriter, r *http.Request) {
+		store, ok := sink.(fodbc.CorporateActionStore)
+		if !ok {
+			http.Error(w, fmt.Sprintf("sink %s cannot read corporate actions", *sinkFlag), http.StatusNotImplemented)
+			return
+		}
+		symbol := r.URL.Query().Get("symbol")
+		if symbol == "" {
+			http.Error(w, "symbol is required", http.StatusBadRequest)
+			return
+		}
+
+		actions, err := store.ReadCorporateActions(r.Context(), symbol)
+		respond(w, actions, err)
+	})
+
+	server := &http.Server{Addr: *addrFlag, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	print(fmt.Sprintf("Serving %s on http://%s\n", *sinkFlag, *addrFlag))
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func respond(w http.ResponseWriter, v interface{}, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	_ "github.com/jakoblorz/dynsql/lib/go-sqlite3"
+	fodbc "github.com/jakoblorz/finance-odbc"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// openSink opens the storage backend described by rawurl:
+//
+//	sqlite://finance.sqlite3          dyn-sqlite3 database file
+//	postgres://user:pw@host/db        PostgreSQL database
+//	file://out                        one JSON lines file per table in out/
+func openSink(ctx context.Context, rawurl string) (fodbc.Sink, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "sqlite", "sqlite3":
+		db, err := sqlx.Connect("dyn-sqlite3", u.Host+u.Path)
+		if err != nil {
+			return nil, err
+		}
+		// SQLite allows a single writer; a single connection keeps the
+		// reads of the download workers from locking out the writes.
+		db.SetMaxOpenConns(1)
+		return fodbc.NewSQLiteSink(db), nil
+
+	case "postgres", "postgresql":
+		db, err := sqlx.Connect("postgres", rawurl)
+		if err != nil {
+			return nil, err
+		}
+		return fodbc.NewPostgresStore(db), nil
+
+	case "file":
+		return fodbc.NewFileSink(u.Host + u.Path)
+	}
+	return nil, fmt.Errorf("unsupported sink %s", rawurl)
+}
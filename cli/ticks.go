@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+	"github.com/piquette/finance-go/datetime"
+)
+
+// priceFlags binds the options shared by the commands working on
+// prices. from is the default start of the time range.
+func priceFlags(fs *flag.FlagSet, cfg *Config, from string) {
+	rangeFlags(fs, cfg, from)
+	fs.StringVar(watchlistFlag, "watchlist", "", "Also use the symbols of these comma separated watchlists: stored ones, ones from the config or .txt and .csv files")
+}
+
+// rangeFlags binds the options selecting the intervals, time range and
+// sessions of prices.
+func rangeFlags(fs *flag.FlagSet, cfg *Config, from string) {
+	fs.StringVar(intervalsFlag, "intervals", strings.Join(cfg.Ticks.Intervals, ","), "Comma separated bar intervals: 1m, 2m, 5m, 15m, 30m, 60m, 90m, 1h, 1d, 5d, 1mo or 3mo")
+	fs.BoolVar(useAllTicksFlag, "all", false, "Use all bar intervals")
+	fs.StringVar(fromFlag, "from", from, "Start at this date (2006-01-02) or relative duration before now (e.g. 30d, 6mo, 5y, ytd, max)")
+	fs.StringVar(toFlag, "to", cfg.Ticks.To, "End at this date (2006-01-02) or relative duration before now")
+	fs.BoolVar(prePostFlag, "prepost", cfg.Ticks.PrePost, "Also download intraday prices from the pre- and post-market sessions")
+	fs.BoolVar(regularFlag, "regular", cfg.Ticks.Regular, "Only keep prices from the regular trading session")
+}
+
+// priceBatch lists the symbols to work on at one interval.
+type priceBatch struct {
+	interval string
+	symbols  []string
+}
+
+// symbolsOf returns every symbol of batches once.
+func symbolsOf(batches []priceBatch) []string {
+	seen := map[string]bool{}
+	symbols := []string{}
+	for _, b := range batches {
+		for _, s := range b.symbols {
+			if !seen[s] {
+				seen[s] = true
+				symbols = append(symbols, s)
+			}
+		}
+	}
+	return symbols
+}
+
+// selectPrices returns the symbols given as args and in the -watchlist
+// watchlists, or else in the config, batched by the intervals given by
+// the price flags, and the time range. Watchlist members with intervals
+// of their own are only batched at those, unless -all is given.
+func selectPrices(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) (batches []priceBatch, from, to time.Time, err error) {
+	members := []fodbc.WatchlistMember{}
+	symbols := cfg.expand(splitList(args...))
+	if len(args) == 0 && *watchlistFlag == "" {
+		symbols = cfg.expand(cfg.Ticks.Symbols)
+	}
+	for _, s := range symbols {
+		members = append(members, fodbc.WatchlistMember{Symbol: s})
+	}
+	listed, err := loadWatchlists(ctx, sink, cfg, *watchlistFlag)
+	if err != nil {
+		return
+	}
+	members = append(members, listed...)
+	if len(members) == 0 {
+		err = fmt.Errorf("no symbols given")
+		return
+	}
+
+	requested := splitList(*intervalsFlag)
+	if *useAllTicksFlag {
+		requested = []string{}
+		for _, i := range fodbc.Intervals {
+			requested = append(requested, string(i))
+		}
+	}
+	intervals := []string{}
+REQUESTED_INTERVALS:
+	for _, i := range requested {
+		if fodbc.IsBarInterval(datetime.Interval(i)) {
+			intervals = append(intervals, i)
+			continue
+		}
+		for _, r := range fodbc.QueryRanges {
+			if string(r) == i {
+				warn(fmt.Sprintf("%s is a query range, not an interval, and is ignored; use -from %s instead", r, r))
+				continue REQUESTED_INTERVALS
+			}
+		}
+		err = fmt.Errorf("unknown interval %q", i)
+		return
+	}
+
+	batched := map[string][]string{}
+	seen := map[[2]string]bool{}
+	for _, m := range members {
+		own := m.IntervalList()
+		if len(own) == 0 || *useAllTicksFlag {
+			own = intervals
+		}
+		if len(own) == 0 {
+			err = fmt.Errorf("no intervals given for %s, use -intervals or -all", m.Symbol)
+			return
+		}
+		for _, i := range own {
+			if !seen[[2]string{i, m.Symbol}] {
+				seen[[2]string{i, m.Symbol}] = true
+				batched[i] = append(batched[i], m.Symbol)
+			}
+		}
+	}
+	for _, i := range fodbc.Intervals {
+		if symbols, ok := batched[string(i)]; ok {
+			batches = append(batches, priceBatch{string(i), symbols})
+		}
+	}
+
+	now := time.Now().UTC()
+	if from, err = fodbc.ParseTimeBound(*fromFlag, now); err != nil {
+		return
+	}
+	to, err = fodbc.ParseTimeBound(*toFlag, now)
+	return
+}
+
+func ticksFlags(fs *flag.FlagSet, cfg *Config) {
+	priceFlags(fs, cfg, cfg.Ticks.From)
+	downloadFlags(fs, cfg)
+}
+
+// downloadFlags binds the options of how prices are downloaded.
+func downloadFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.BoolVar(incrementalFlag, "incremental", cfg.Ticks.Incremental, "Only download prices newer than the last stored one")
+	fs.BoolVar(upsertFlag, "upsert", cfg.Ticks.Upsert, "Replace stored prices that were revised since they were downloaded")
+	fs.BoolVar(backfillFlag, "backfill", cfg.Ticks.Backfill, "Also download all prices older than the first stored one back to the first trade date")
+	fs.BoolVar(actionsFlag, "actions", cfg.Ticks.Actions, "Also download the dividends and splits of the symbols")
+	fs.BoolVar(rangesFlag, "ranges", cfg.Ticks.Ranges, "Also record which query ranges (1mo, 5y, max, ...) the downloaded intervals are valid for")
+}
+
+// runTicks downloads the prices of the given symbols at every selected
+// interval.
+func runTicks(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) error {
+	batches, from, to, err := selectPrices(ctx, sink, cfg, args)
+	if err != nil {
+		return err
+	}
+
+	writeMode := fodbc.KeepStored
+	if *upsertFlag {
+		writeMode = fodbc.Upsert
+	}
+
+	validRanges, recordRanges := sink.(fodbc.ValidRangeWriter)
+	recordRanges = recordRanges && *rangesFlag
+	if *rangesFlag && !recordRanges {
+		warn(fmt.Sprintf("Sink %s cannot record valid ranges, skipping -ranges", *sinkFlag))
+	}
+
+	tickStats := fodbc.WriteStats{}
+	for _, batch := range batches {
+		interval, values := batch.interval, batch.symbols
+		cancel := spin(
+			fmt.Sprintf("Downloading Historical Prices with an interval of %s:%s %d Download(s) required ", interval, tickIntervalPadding[interval], len(values)),
+			"",
+		)
+		cancel(fetchAll(ctx, interval, values, func(ctx context.Context, value string) (func(context.Context) error, error) {
+			ticks, err := fetchNewTicks(ctx, sink, value, interval, from, to)
+			if err != nil {
+				return nil, err
+			}
+			if *regularFlag {
+				ticks = fodbc.FilterSessions(ticks, fodbc.SessionRegular)
+			}
+
+			return func(ctx context.Context) error {
+				if recordRanges && len(ticks) > 0 {
+					err := validRanges.WriteValidRanges(ctx, ticks[len(ticks)-1].ValidRanges())
+					if err != nil {
+						return err
+					}
+				}
+
+				stats, err := sink.WriteTicks(ctx, ticks, writeMode)
+				tickStats.Add(stats)
+				return err
+			}, nil
+		}))
+	}
+
+	if *actionsFlag {
+		values := symbolsOf(batches)
+		cancel := spin(
+			fmt.Sprintf("Downloading Corporate Actions: %d Download(s) required ", len(values)),
+			"",
+		)
+		cancel(fetchCorporateActions(ctx, sink, values, from, to))
+	}
+
+	print(fmt.Sprintf("Ticks: %s\n", tickStats))
+	return nil
+}
+
+// fetchNewTicks downloads the bars of symbol between from and to that
+// sink does not hold yet. With -incremental the download starts at the
+// newest stored bar; with -backfill it additionally walks back from the
+// oldest known bar to the first trade date, fetched or stored, or as
+// far back as Yahoo keeps bars of the interval, so the history found
+// missing by earlier runs is not walked again. With -upsert stored bars are returned as
+// well so the sink can pick up revisions.
+func fetchNewTicks(ctx context.Context, sink fodbc.Sink, symbol, interval string, from, to time.Time) ([]fodbc.Tick, error) {
+	req := fodbc.TickRequest{
+		Symbol:         symbol,
+		Interval:       datetime.Interval(interval),
+		Start:          from,
+		End:            to,
+		IncludePrePost: *prePostFlag,
+	}
+
+	var first, last time.Time
+	stored := false
+	if ranges, ok := sink.(fodbc.TickRangeReader); ok && (*incrementalFlag || *backfillFlag) {
+		var err error
+		first, last, stored, err = ranges.TickRange(ctx, symbol, interval)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if stored && *incrementalFlag {
+		if last.After(req.Start) && last.Before(req.End) {
+			req.Start = last
+		}
+	}
+
+	fetched, err := fodbc.FetchTicks(ctx, provider, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if *backfillFlag {
+		oldest, firstTradeDate := first, time.Time{}
+		for _, t := range fetched {
+			if !stored && (oldest.IsZero() || t.Timestamp.Before(oldest)) {
+				oldest = t.Timestamp.Time
+			}
+			firstTradeDate = t.FirstTradeDate.Time
+		}
+		if dates, ok := sink.(fodbc.FirstTradeDateReader); ok && firstTradeDate.IsZero() && stored {
+			if firstTradeDate, _, err = dates.FirstTradeDate(ctx, symbol); err != nil {
+				return nil, err
+			}
+		}
+
+		// Without a first trade date, intraday bars are walked back as far
+		// as Yahoo keeps them.
+		start := firstTradeDate
+		if h := fodbc.MaxHistory(req.Interval); h > 0 && start.Before(time.Now().Add(-h)) {
+			start = time.Now().Add(-h)
+		}
+
+		if oldest.IsZero() || start.IsZero() {
+			warn(fmt.Sprintf("No bars of %s at %s with a first trade date to backfill to, skipping backfill", symbol, interval))
+		} else if start.Before(oldest) {
+			backfilled, err := fodbc.BackfillTicks(ctx, provider, fodbc.TickRequest{
+				Symbol:         symbol,
+				Interval:       req.Interval,
+				Start:          start,
+				End:            oldest,
+				IncludePrePost: req.IncludePrePost,
+			})
+			if err != nil {
+				return nil, err
+			}
+			fetched = append(backfilled, fetched...)
+		}
+	}
+
+	if !stored || *upsertFlag {
+		return fetched, nil
+	}
+
+	ticks := []fodbc.Tick{}
+	for _, t := range fetched {
+		if !t.Timestamp.Before(first) && !t.Timestamp.After(last) {
+			continue
+		}
+		ticks = append(ticks, t)
+	}
+	return ticks, nil
+}
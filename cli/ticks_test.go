@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+	"github.com/piquette/finance-go"
+	"github.com/piquette/finance-go/chart"
+	"github.com/piquette/finance-go/datetime"
+)
+
+// chartRecorder serves bar on the first chart request and no bars after
+// it, keeping all requests.
+type chartRecorder struct {
+	fodbc.Provider
+
+	bar      *finance.ChartBar
+	meta     finance.ChartMeta
+	requests []*chart.Params
+}
+
+func (p *chartRecorder) GetChart(ctx context.Context, params *chart.Params) fodbc.ChartIterator {
+	p.requests = append(p.requests, params)
+	if len(p.requests) > 1 || p.bar == nil {
+		return fodbc.NewChartSlice(p.meta, nil, nil)
+	}
+	return fodbc.NewChartSlice(p.meta, []finance.ChartBar{*p.bar}, nil)
+}
+
+// storedRange holds bars from first to last, stored with the first
+// trade date firstTrade unless it is zero.
+type storedRange struct {
+	fodbc.Sink
+
+	first, last, firstTrade time.Time
+}
+
+func (s storedRange) TickRange(ctx context.Context, symbol, granularity string) (first, last time.Time, ok bool, err error) {
+	return s.first, s.last, true, nil
+}
+
+func (s storedRange) FirstTradeDate(ctx context.Context, symbol string) (date time.Time, ok bool, err error) {
+	return s.firstTrade, !s.firstTrade.IsZero(), nil
+}
+
+func TestFetchNewTicksBackfill(t *testing.T) {
+	defer func(p fodbc.Provider) { provider = p }(provider)
+	*incrementalFlag, *backfillFlag, *upsertFlag, *prePostFlag = true, true, false, false
+
+	now := time.Now().UTC()
+	bar := &finance.ChartBar{Timestamp: int(now.Add(-30 * time.Minute).Unix())}
+	meta := finance.ChartMeta{FirstTradeDate: int(time.Date(1980, 12, 12, 14, 30, 0, 0, time.UTC).Unix())}
+
+	for _, c := range []struct {
+		name       string
+		interval   string
+		bar        *finance.ChartBar
+		oldest     time.Duration
+		firstTrade time.Time
+		requests   int
+	}{
+		{"no new bars, no first trade date", "1d", nil, 10 * 24 * time.Hour, time.Time{}, 1},
+		{"no new bars, stored first trade date", "1d", nil, 10 * 24 * time.Hour, time.Unix(int64(meta.FirstTradeDate), 0), 2},
+		{"no new intraday bars, no first trade date", "1m", nil, 10 * 24 * time.Hour, time.Time{}, 2},
+		{"history Yahoo no longer keeps", "1m", bar, 31 * 24 * time.Hour, time.Time{}, 1},
+		{"history Yahoo keeps", "1m", bar, 10 * 24 * time.Hour, time.Time{}, 2},
+	} {
+		p := &chartRecorder{bar: c.bar, meta: meta}
+		provider = p
+		sink := storedRange{first: now.Add(-c.oldest), last: now.Add(-time.Hour), firstTrade: c.firstTrade}
+		if _, err := fetchNewTicks(context.Background(), sink, "AAPL", c.interval, now.Add(-100*time.Hour), now); err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		if len(p.requests) != c.requests {
+			t.Errorf("%s: made %d chart requests, want %d", c.name, len(p.requests), c.requests)
+		}
+		h := fodbc.MaxHistory(datetime.Interval(c.interval))
+		for _, r := range p.requests[1:] {
+			if start := time.Unix(int64(r.Start.Unix()), 0); h > 0 && start.Before(now.Add(-h-time.Minute)) {
+				t.Errorf("%s: backfilled from %s, before the history Yahoo keeps", c.name, start)
+			}
+		}
+	}
+}
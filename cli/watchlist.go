@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	fodbc "github.com/jakoblorz/finance-odbc"
+)
+
+// loadWatchlist returns the members of the watchlist name: the one in
+// the file name points to, the one of the config or the one stored in
+// sink.
+func loadWatchlist(ctx context.Context, sink fodbc.Sink, cfg *Config, name string) ([]fodbc.WatchlistMember, error) {
+	if fodbc.IsWatchlistFile(name) {
+		_, ms, err := fodbc.LoadWatchlistFile(name)
+		return ms, err
+	}
+
+	if symbols, ok := cfg.Watchlists[name]; ok {
+		ms := []fodbc.WatchlistMember{}
+		for _, symbol := range symbols {
+			ms = append(ms, fodbc.WatchlistMember{Watchlist: name, Symbol: symbol})
+		}
+		return ms, nil
+	}
+
+	store, ok := sink.(fodbc.WatchlistStore)
+	if !ok {
+		return nil, fmt.Errorf("unknown watchlist %q", name)
+	}
+	ms, err := store.ReadWatchlist(ctx, name)
+	if err == nil && len(ms) == 0 {
+		err = fmt.Errorf("unknown or empty watchlist %q", name)
+	}
+	return ms, err
+}
+
+// loadWatchlists returns the members of all comma separated watchlists
+// in names.
+func loadWatchlists(ctx context.Context, sink fodbc.Sink, cfg *Config, names string) ([]fodbc.WatchlistMember, error) {
+	members := []fodbc.WatchlistMember{}
+	for _, name := range splitList(names) {
+		ms, err := loadWatchlist(ctx, sink, cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, ms...)
+	}
+	return members, nil
+}
+
+const watchlistUsage = `usage:
+  watchlist                                            list the stored watchlists
+  watchlist show <name>                                list the members of a watchlist
+  watchlist add [-class c] [-intervals i,...] <name> <symbol>...
+  watchlist remove <name> <symbol>...
+  watchlist import <file> [name]                       store a .txt or .csv watchlist`
+
+// runWatchlist lists and edits the watchlists stored in sink.
+func runWatchlist(ctx context.Context, sink fodbc.Sink, cfg *Config, args []string) error {
+	store, ok := sink.(fodbc.WatchlistStore)
+	if !ok {
+		return fmt.Errorf("sink %s cannot store watchlists", *sinkFlag)
+	}
+
+	if len(args) == 0 {
+		names, err := store.WatchlistNames(ctx)
+		if err != nil {
+			return err
+		}
+		print(fmt.Sprintf("Watchlists: %d\n", len(names)))
+		for _, name := range names {
+			ms, err := store.ReadWatchlist(ctx, name)
+			if err != nil {
+				return err
+			}
+			print(fmt.Sprintf("  %s (%d symbols)\n", name, len(ms)))
+		}
+		return nil
+	}
+
+	switch action, args := args[0], args[1:]; action {
+	case "show":
+		if len(args) != 1 {
+			return errors.New(watchlistUsage)
+		}
+		ms, err := loadWatchlist(ctx, sink, cfg, args[0])
+		if err != nil {
+			return err
+		}
+		print(fmt.Sprintf("%s: %d\n", args[0], len(ms)))
+		for _, m := range ms {
+			print(fmt.Sprintf("  %s\n", strings.TrimSpace(fmt.Sprintf("%-12s %-14s %s", m.Symbol, m.Class, m.Intervals))))
+		}
+		return nil
+
+	case "add":
+		fs := flag.NewFlagSet("watchlist add", flag.ContinueOnError)
+		class := fs.String("class", "", "Asset class of the symbols")
+		intervals := fs.String("intervals", "", "Comma separated bar intervals to download the symbols at instead of the default ones")
+		args, err := parseFlags(fs, args)
+		if err != nil {
+			return err
+		}
+		if len(args) < 2 {
+			return errors.New(watchlistUsage)
+		}
+
+		ms := []fodbc.WatchlistMember{}
+		for _, symbol := range splitList(args[1:]...) {
+			m, err := fodbc.NewWatchlistMember(args[0], symbol, *class, splitList(*intervals))
+			if err != nil {
+				return err
+			}
+			ms = append(ms, m)
+		}
+		return store.AddToWatchlist(ctx, ms)
+
+	case "remove":
+		if len(args) < 2 {
+			return errors.New(watchlistUsage)
+		}
+		return store.RemoveFromWatchlist(ctx, args[0], splitList(args[1:]...))
+
+	case "import":
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New(watchlistUsage)
+		}
+		name, ms, err := fodbc.LoadWatchlistFile(args[0])
+		if err != nil {
+			return err
+		}
+		if len(args) == 2 {
+			name = args[1]
+			for i := range ms {
+				ms[i].Watchlist = name
+			}
+		}
+		if err := store.AddToWatchlist(ctx, ms); err != nil {
+			return err
+		}
+		print(fmt.Sprintf("Imported %d symbols into %s\n", len(ms), name))
+		return nil
+	}
+	return errors.New(watchlistUsage)
+}
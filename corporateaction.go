@@ -0,0 +1,120 @@
+package odbc
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const CorporateActionTableName = "corporate_actions"
+
+// Types of corporate actions.
+const (
+	ActionDividend = "dividend"
+	ActionSplit    = "split"
+)
+
+// CorporateAction is a dividend or split of Symbol taking effect at
+// Timestamp (the ex-date). Dividends pay Amount per share, splits turn
+// Denominator shares into Numerator shares.
+type CorporateAction struct {
+	DBEntry
+
+	Symbol      string          `db:"symbol" json:"symbol"`
+	Type        string          `db:"type" json:"type"`
+	Timestamp   Timestamp       `db:"timestamp" json:"timestamp"`
+	Amount      decimal.Decimal `db:"amount" json:"amount"`
+	Numerator   decimal.Decimal `db:"numerator" json:"numerator"`
+	Denominator decimal.Decimal `db:"denominator" json:"denominator"`
+}
+
+// CorporateActionKeyColumns is the natural key of the corporate action
+// table.
+var CorporateActionKeyColumns = []string{"symbol", "type", "timestamp"}
+
+type corporateActionKey struct {
+	Symbol    string
+	Type      string
+	Timestamp int64
+}
+
+func (a *CorporateAction) key() corporateActionKey {
+	return corporateActionKey{a.Symbol, a.Type, a.Timestamp.Unix()}
+}
+
+// CorporateActionProvider is implemented by providers that can list the
+// dividends and splits of a symbol between start and end.
+type CorporateActionProvider interface {
+	GetCorporateActions(ctx context.Context, symbol string, start, end time.Time) ([]CorporateAction, error)
+}
+
+// BackAdjust returns ticks with open, high, low, close and volume
+// adjusted for all later splits and dividends in actions, so the series
+// is continuous with the most recent prices. Dividend amounts are taken
+// to be split adjusted, as Yahoo reports them; each one scales earlier
+// prices by 1 - amount / previous close.
+func BackAdjust(ticks []Tick, actions []CorporateAction) []Tick {
+	adjusted := make([]Tick, len(ticks))
+	copy(adjusted, ticks)
+	sort.Slice(adjusted, func(i, j int) bool { return adjusted[i].Timestamp.Before(adjusted[j].Timestamp.Time) })
+	if len(adjusted) == 0 {
+		return adjusted
+	}
+
+	sorted := []CorporateAction{}
+	for _, a := range actions {
+		if a.Symbol == adjusted[0].Symbol {
+			sorted = append(sorted, a)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp.Time) })
+
+	one := decimal.New(1, 0)
+	scale := func(before Timestamp, price, volume decimal.Decimal) {
+		for i := range adjusted {
+			t := &adjusted[i]
+			if !t.Timestamp.Before(before.Time) {
+				break
+			}
+			t.Open = t.Open.Mul(price)
+			t.High = t.High.Mul(price)
+			t.Low = t.Low.Mul(price)
+			t.Close = t.Close.Mul(price)
+			t.Volume = int(decimal.New(int64(t.Volume), 0).Mul(volume).Round(0).IntPart())
+		}
+	}
+
+	// Splits first, so the previous closes dividends are measured against
+	// are split adjusted like the dividend amounts.
+	for _, a := range sorted {
+		if a.Type != ActionSplit || a.Numerator.IsZero() || a.Denominator.IsZero() {
+			continue
+		}
+		ratio := a.Numerator.Div(a.Denominator)
+		scale(a.Timestamp, one.Div(ratio), ratio)
+	}
+	for _, a := range sorted {
+		if a.Type != ActionDividend {
+			continue
+		}
+
+		var prev *Tick
+		for i := range adjusted {
+			if !adjusted[i].Timestamp.Before(a.Timestamp.Time) {
+				break
+			}
+			prev = &adjusted[i]
+		}
+		if prev == nil || !prev.Close.GreaterThan(a.Amount) {
+			continue
+		}
+		scale(a.Timestamp, one.Sub(a.Amount.Div(prev.Close)), one)
+	}
+
+	for i := range adjusted {
+		adjusted[i].AdjClose = adjusted[i].Close
+	}
+	return adjusted
+}
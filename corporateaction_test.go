@@ -0,0 +1,67 @@
+package odbc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/piquette/finance-go"
+	"github.com/piquette/finance-go/form"
+	"github.com/shopspring/decimal"
+)
+
+type eventsBackend string
+
+func (b eventsBackend) Call(path string, body *form.Values, ctx *context.Context, v interface{}) error {
+	return json.Unmarshal([]byte(b), v)
+}
+
+func TestYahooCorporateActions(t *testing.T) {
+	finance.SetBackend(finance.YFinBackend, eventsBackend(`{"chart":{"result":[{"events":{
+		"dividends":{"1700000000":{"amount":0.24,"date":1700000000}},
+		"splits":{"1600000000":{"date":1600000000,"numerator":4,"denominator":1,"splitRatio":"4:1"}}
+	}}],"error":null}}`))
+	defer finance.SetBackend(finance.YFinBackend, nil)
+
+	as, err := YahooProvider{}.GetCorporateActions(context.Background(), "AAPL", time.Unix(0, 0), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != 2 {
+		t.Fatalf("got %d actions, want 2", len(as))
+	}
+	if a := as[0]; a.Type != ActionSplit || !a.Numerator.Equal(decimal.New(4, 0)) || !a.Denominator.Equal(decimal.New(1, 0)) {
+		t.Errorf("unexpected split %+v", a)
+	}
+	if a := as[1]; a.Type != ActionDividend || a.Symbol != "AAPL" || !a.Amount.Equal(decimal.New(24, -2)) {
+		t.Errorf("unexpected dividend %+v", a)
+	}
+}
+
+func TestBackAdjust(t *testing.T) {
+	bar := func(ts int, price int64, volume int) Tick {
+		p := decimal.New(price, 0)
+		return Tick{Symbol: "X", Timestamp: NewTimestamp(ts, time.UTC), Open: p, High: p, Low: p, Close: p, Volume: volume}
+	}
+	ticks := []Tick{bar(1, 200, 10), bar(2, 100, 20), bar(3, 50, 30)}
+	actions := []CorporateAction{
+		{Symbol: "X", Type: ActionSplit, Timestamp: NewTimestamp(2, time.UTC), Numerator: decimal.New(2, 0), Denominator: decimal.New(1, 0)},
+		{Symbol: "X", Type: ActionDividend, Timestamp: NewTimestamp(3, time.UTC), Amount: decimal.New(10, 0)},
+		{Symbol: "Y", Type: ActionSplit, Timestamp: NewTimestamp(3, time.UTC), Numerator: decimal.New(10, 0), Denominator: decimal.New(1, 0)},
+	}
+
+	adjusted := BackAdjust(ticks, actions)
+	for i, want := range []struct {
+		close  int64
+		volume int
+	}{{90, 20}, {90, 20}, {50, 30}} {
+		a := adjusted[i]
+		if !a.Close.Equal(decimal.New(want.close, 0)) || !a.AdjClose.Equal(a.Close) || !a.Open.Equal(a.Close) || a.Volume != want.volume {
+			t.Errorf("bar %d adjusted to close %s, volume %d; want %d, %d", i, a.Close, a.Volume, want.close, want.volume)
+		}
+	}
+	if !ticks[0].Close.Equal(decimal.New(200, 0)) {
+		t.Error("BackAdjust modified its input")
+	}
+}
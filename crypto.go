@@ -1,8 +1,10 @@
 package odbc
 
 import (
+	"context"
+	"strings"
+
 	"github.com/piquette/finance-go"
-	"github.com/piquette/finance-go/crypto"
 )
 
 type CryptoPair struct {
@@ -16,6 +18,16 @@ type CryptoPair struct {
 	VolumeAllCurrencies int    `db:"volume_all_currencies" json:"volume_all_currencies"`
 }
 
+var cryptoAssetClass = AssetClass{
+	Name:      strings.ToLower(string(finance.QuoteTypeCryptoPair)),
+	Title:     "Crypto",
+	QuoteType: finance.QuoteTypeCryptoPair,
+
+	Convert: NewAnonCryptoFromAPI,
+	NewAPI:  func() interface{} { return &finance.CryptoPair{} },
+	Schema:  CryptoPair{},
+}
+
 func NewAnonCryptoFromAPI(c interface{}) (p interface{}, ok bool) {
 	var apiCryptoPair *finance.CryptoPair
 	apiCryptoPair, ok = c.(*finance.CryptoPair)
@@ -28,7 +40,7 @@ func NewAnonCryptoFromAPI(c interface{}) (p interface{}, ok bool) {
 }
 
 func GetAnonCryptoFromAPI(symbol string) (interface{}, error) {
-	return crypto.Get(symbol)
+	return DefaultProvider.GetAsset(context.Background(), finance.QuoteTypeCryptoPair, symbol)
 }
 
 func NewCryptoFromAPI(c *finance.CryptoPair) (cp CryptoPair, ok bool) {
@@ -1,8 +1,11 @@
 package odbc
 
 import (
+	"context"
+	"strings"
+
 	"github.com/piquette/finance-go"
-	"github.com/piquette/finance-go/equity"
+	"github.com/shopspring/decimal"
 )
 
 type Equity struct {
@@ -11,25 +14,36 @@ type Equity struct {
 	LongName  string `db:"long_name" json:"long_name"`
 	MarketCap int64  `db:"market_cap" json:"market_cap"`
 
-	EarningsTimestamp      int `db:"earnings_timestamp" json:"earnings_timestamp"`
-	EarningsTimestampStart int `db:"earnings_timestamp_start" json:"earnings_timestamp_start"`
-	EarningsTimestampEnd   int `db:"earnings_timestamp_end" json:"earnings_timestamp_end"`
+	EarningsTimestamp      Timestamp `db:"earnings_timestamp" json:"earnings_timestamp"`
+	EarningsTimestampStart Timestamp `db:"earnings_timestamp_start" json:"earnings_timestamp_start"`
+	EarningsTimestampEnd   Timestamp `db:"earnings_timestamp_end" json:"earnings_timestamp_end"`
 
-	TrailingTwelveMonthsEarningsPerShare float64 `db:"trailing_twelve_months_earnings_per_share" json:"trailing_twelve_months_earnings_per_share"`
-	TrailingAnnualDividendRate           float64 `db:"trailing_annual_dividend_rate" json:"trailing_annual_dividend_rate"`
-	TrailingAnnualDividendYield          float64 `db:"trailing_annual_dividend_yield" json:"trailing_annual_dividend_yield"`
-	TrailingPriceToEarnings              float64 `db:"trailing_price_to_earnings" json:"trailing_price_to_earnings"`
+	TrailingTwelveMonthsEarningsPerShare decimal.Decimal `db:"trailing_twelve_months_earnings_per_share" json:"trailing_twelve_months_earnings_per_share"`
+	TrailingAnnualDividendRate           decimal.Decimal `db:"trailing_annual_dividend_rate" json:"trailing_annual_dividend_rate"`
+	TrailingAnnualDividendYield          decimal.Decimal `db:"trailing_annual_dividend_yield" json:"trailing_annual_dividend_yield"`
+	TrailingPriceToEarnings              decimal.Decimal `db:"trailing_price_to_earnings" json:"trailing_price_to_earnings"`
 
-	ForwardEarningsPerShare float64 `db:"forward_earnings_per_share" json:"forward_earnings_per_share"`
-	ForwardPriceToEarnings  float64 `db:"forward_price_to_earnings" json:"forward_price_to_earnings"`
+	ForwardEarningsPerShare decimal.Decimal `db:"forward_earnings_per_share" json:"forward_earnings_per_share"`
+	ForwardPriceToEarnings  decimal.Decimal `db:"forward_price_to_earnings" json:"forward_price_to_earnings"`
 
-	DividendRate int     `db:"dividend_rate" json:"dividend_rate"`
-	BookValue    float64 `db:"book_value" json:"book_value"`
-	PriceToBook  float64 `db:"price_to_book" json:"price_to_book"`
+	DividendRate int             `db:"dividend_rate" json:"dividend_rate"`
+	BookValue    decimal.Decimal `db:"book_value" json:"book_value"`
+	PriceToBook  decimal.Decimal `db:"price_to_book" json:"price_to_book"`
 
 	SharesOutstanding int `db:"shares_outstanding" json:"shares_outstanding"`
 }
 
+var equityAssetClass = AssetClass{
+	Name:      strings.ToLower(string(finance.QuoteTypeEquity)),
+	Title:     "Equity",
+	QuoteType: finance.QuoteTypeEquity,
+	Aliases:   []string{"ecnquote"},
+
+	Convert: NewAnonEquityFromAPI,
+	NewAPI:  func() interface{} { return &finance.Equity{} },
+	Schema:  Equity{},
+}
+
 func NewAnonEquityFromAPI(c interface{}) (e interface{}, ok bool) {
 	var apiEquity *finance.Equity
 	apiEquity, ok = c.(*finance.Equity)
@@ -42,7 +56,7 @@ func NewAnonEquityFromAPI(c interface{}) (e interface{}, ok bool) {
 }
 
 func GetAnonEquityFromAPI(symbol string) (interface{}, error) {
-	return equity.Get(symbol)
+	return DefaultProvider.GetAsset(context.Background(), finance.QuoteTypeEquity, symbol)
 }
 
 func NewEquityFromAPI(e *finance.Equity) (eq Equity, ok bool) {
@@ -57,21 +71,21 @@ func NewEquityFromAPI(e *finance.Equity) (eq Equity, ok bool) {
 		LongName:  e.LongName,
 		MarketCap: e.MarketCap,
 
-		EarningsTimestamp:      e.EarningsTimestamp,
-		EarningsTimestampStart: e.EarningsTimestampStart,
-		EarningsTimestampEnd:   e.EarningsTimestampEnd,
+		EarningsTimestamp:      NewTimestamp(e.EarningsTimestamp, quoteLocation(&e.Quote)),
+		EarningsTimestampStart: NewTimestamp(e.EarningsTimestampStart, quoteLocation(&e.Quote)),
+		EarningsTimestampEnd:   NewTimestamp(e.EarningsTimestampEnd, quoteLocation(&e.Quote)),
 
-		TrailingTwelveMonthsEarningsPerShare: e.EpsTrailingTwelveMonths,
-		TrailingAnnualDividendRate:           e.TrailingAnnualDividendRate,
-		TrailingAnnualDividendYield:          e.TrailingAnnualDividendYield,
-		TrailingPriceToEarnings:              e.TrailingPE,
+		TrailingTwelveMonthsEarningsPerShare: NewPrice(e.EpsTrailingTwelveMonths, e.CurrencyID),
+		TrailingAnnualDividendRate:           NewPrice(e.TrailingAnnualDividendRate, e.CurrencyID),
+		TrailingAnnualDividendYield:          NewRatio(e.TrailingAnnualDividendYield),
+		TrailingPriceToEarnings:              NewRatio(e.TrailingPE),
 
-		ForwardEarningsPerShare: e.EpsForward,
-		ForwardPriceToEarnings:  e.ForwardPE,
+		ForwardEarningsPerShare: NewPrice(e.EpsForward, e.CurrencyID),
+		ForwardPriceToEarnings:  NewRatio(e.ForwardPE),
 
 		DividendRate: e.DividendDate,
-		BookValue:    e.BookValue,
-		PriceToBook:  e.PriceToBook,
+		BookValue:    NewPrice(e.BookValue, e.CurrencyID),
+		PriceToBook:  NewRatio(e.PriceToBook),
 
 		SharesOutstanding: e.SharesOutstanding,
 	}
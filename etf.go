@@ -1,16 +1,29 @@
 package odbc
 
 import (
+	"context"
+	"strings"
+
 	"github.com/piquette/finance-go"
-	"github.com/piquette/finance-go/etf"
+	"github.com/shopspring/decimal"
 )
 
 type ETF struct {
 	Quote
 
-	YTDReturn                    float64 `db:"ytd_return" json:"ytd_return"`
-	TrailingThreeMonthReturns    float64 `db:"trailing_three_month_returns" json:"trailing_three_month_returns"`
-	TrailingThreeMonthNavReturns float64 `db:"trailing_three_month_nav_returns" json:"trailing_three_month_nav_returns"`
+	YTDReturn                    decimal.Decimal `db:"ytd_return" json:"ytd_return"`
+	TrailingThreeMonthReturns    decimal.Decimal `db:"trailing_three_month_returns" json:"trailing_three_month_returns"`
+	TrailingThreeMonthNavReturns decimal.Decimal `db:"trailing_three_month_nav_returns" json:"trailing_three_month_nav_returns"`
+}
+
+var etfAssetClass = AssetClass{
+	Name:      strings.ToLower(string(finance.QuoteTypeETF)),
+	Title:     "ETF",
+	QuoteType: finance.QuoteTypeETF,
+
+	Convert: NewAnonETFFromAPI,
+	NewAPI:  func() interface{} { return &finance.ETF{} },
+	Schema:  ETF{},
 }
 
 func NewAnonETFFromAPI(c interface{}) (e interface{}, ok bool) {
@@ -25,7 +38,7 @@ func NewAnonETFFromAPI(c interface{}) (e interface{}, ok bool) {
 }
 
 func GetAnonETFFromAPI(symbol string) (interface{}, error) {
-	return etf.Get(symbol)
+	return DefaultProvider.GetAsset(context.Background(), finance.QuoteTypeETF, symbol)
 }
 
 func NewETFFromAPI(e *finance.ETF) (etf ETF, ok bool) {
@@ -37,9 +50,9 @@ func NewETFFromAPI(e *finance.ETF) (etf ETF, ok bool) {
 	etf = ETF{
 		Quote: NewQuoteFromAPI(&e.Quote),
 
-		YTDReturn:                    e.YTDReturn,
-		TrailingThreeMonthReturns:    e.TrailingThreeMonthReturns,
-		TrailingThreeMonthNavReturns: e.TrailingThreeMonthNavReturns,
+		YTDReturn:                    NewRatio(e.YTDReturn),
+		TrailingThreeMonthReturns:    NewRatio(e.TrailingThreeMonthReturns),
+		TrailingThreeMonthNavReturns: NewRatio(e.TrailingThreeMonthNavReturns),
 	}
 	return
 }
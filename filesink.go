@@ -0,0 +1,548 @@
+package odbc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileSink appends every quote as one JSON line to <dir>/<table>.jsonl.
+// Ticks are kept unique by their natural key and watchlist members can
+// be removed, so <dir>/ticks.jsonl and <dir>/watchlists.jsonl are held
+// in memory and rewritten on Flush whenever they changed.
+type FileSink struct {
+	dir string
+
+	mu      sync.Mutex
+	files   map[string]*os.File
+	writers map[string]*bufio.Writer
+
+	ticks      []Tick
+	tickIndex  map[TickKey]int
+	ticksDirty bool
+
+	validRanges map[TickValidRange]bool
+
+	actions     []CorporateAction
+	actionIndex map[corporateActionKey]bool
+
+	watchlists      []WatchlistMember
+	watchlistsDirty bool
+}
+
+func NewFileSink(dir string) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileSink{
+		dir:     dir,
+		files:   map[string]*os.File{},
+		writers: map[string]*bufio.Writer{},
+	}, nil
+}
+
+func (s *FileSink) path(table string) string {
+	return filepath.Join(s.dir, table+".jsonl")
+}
+
+func (s *FileSink) writer(table string) (*bufio.Writer, error) {
+	if w, ok := s.writers[table]; ok {
+		return w, nil
+	}
+	if err := CheckTable(table); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(s.path(table), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s.files[table] = f
+	s.writers[table] = bufio.NewWriter(f)
+	return s.writers[table], nil
+}
+
+func (s *FileSink) write(table string, v interface{}) error {
+	w, err := s.writer(table)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
+
+func (s *FileSink) loadTicks() error {
+	if s.tickIndex != nil {
+		return nil
+	}
+	s.ticks = []Tick{}
+	s.tickIndex = map[TickKey]int{}
+
+	f, err := os.Open(s.path(TickTableName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		t := Tick{}
+		if err := json.Unmarshal(scanner.Bytes(), &t); err != nil {
+			return err
+		}
+
+		if i, ok := s.tickIndex[t.Key()]; ok {
+			s.ticks[i] = t
+			s.ticksDirty = true
+			continue
+		}
+		s.tickIndex[t.Key()] = len(s.ticks)
+		s.ticks = append(s.ticks, t)
+	}
+	return scanner.Err()
+}
+
+func (s *FileSink) flushTicks() error {
+	if !s.ticksDirty {
+		return nil
+	}
+
+	err := s.rewrite(TickTableName, func(enc *json.Encoder) error {
+		for _, t := range s.ticks {
+			if err := enc.Encode(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.ticksDirty = false
+	return nil
+}
+
+// rewrite replaces <dir>/<table>.jsonl with the lines written by encode.
+func (s *FileSink) rewrite(table string, encode func(enc *json.Encoder) error) error {
+	tmp := s.path(table) + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	if err := encode(json.NewEncoder(w)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(table))
+}
+
+func (s *FileSink) WriteQuotes(ctx context.Context, table string, quotes []interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, q := range quotes {
+		if err := s.write(table, q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileSink) WriteTicks(ctx context.Context, ticks []Tick, mode WriteMode) (WriteStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := WriteStats{}
+	if err := s.loadTicks(); err != nil {
+		return stats, err
+	}
+
+	for _, t := range uniqueTicks(ticks, mode) {
+		var stored *Tick
+		i, ok := s.tickIndex[t.Key()]
+		if ok {
+			stored = &s.ticks[i]
+		}
+
+		insert, update := stats.classify(&t, stored, mode)
+		switch {
+		case insert:
+			s.tickIndex[t.Key()] = len(s.ticks)
+			s.ticks = append(s.ticks, t)
+		case update:
+			s.ticks[i] = t
+		default:
+			continue
+		}
+		s.ticksDirty = true
+	}
+	return stats, nil
+}
+
+func (s *FileSink) loadValidRanges() error {
+	if s.validRanges != nil {
+		return nil
+	}
+	s.validRanges = map[TickValidRange]bool{}
+
+	f, err := os.Open(s.path(TickValidRangeTableName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		r := TickValidRange{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return err
+		}
+		s.validRanges[r] = true
+	}
+	return scanner.Err()
+}
+
+func (s *FileSink) WriteValidRanges(ctx context.Context, rs []TickValidRange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadValidRanges(); err != nil {
+		return err
+	}
+
+	for _, r := range rs {
+		if s.validRanges[r] {
+			continue
+		}
+		if err := s.write(TickValidRangeTableName, r); err != nil {
+			return err
+		}
+		s.validRanges[r] = true
+	}
+	return nil
+}
+
+func (s *FileSink) loadCorporateActions() error {
+	if s.actionIndex != nil {
+		return nil
+	}
+	s.actions = []CorporateAction{}
+	s.actionIndex = map[corporateActionKey]bool{}
+
+	f, err := os.Open(s.path(CorporateActionTableName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		a := CorporateAction{}
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			return err
+		}
+		if !s.actionIndex[a.key()] {
+			s.actionIndex[a.key()] = true
+			s.actions = append(s.actions, a)
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *FileSink) WriteCorporateActions(ctx context.Context, as []CorporateAction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadCorporateActions(); err != nil {
+		return err
+	}
+
+	for _, a := range as {
+		if s.actionIndex[a.key()] {
+			continue
+		}
+		if err := s.write(CorporateActionTableName, a); err != nil {
+			return err
+		}
+		s.actionIndex[a.key()] = true
+		s.actions = append(s.actions, a)
+	}
+	return nil
+}
+
+func (s *FileSink) ReadCorporateActions(ctx context.Context, symbol string) ([]CorporateAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadCorporateActions(); err != nil {
+		return nil, err
+	}
+
+	as := []CorporateAction{}
+	for _, a := range s.actions {
+		if a.Symbol == symbol {
+			as = append(as, a)
+		}
+	}
+	sort.Slice(as, func(i, j int) bool { return as[i].Timestamp.Before(as[j].Timestamp.Time) })
+	return as, nil
+}
+
+func (s *FileSink) loadWatchlists() error {
+	if s.watchlists != nil {
+		return nil
+	}
+	s.watchlists = []WatchlistMember{}
+
+	f, err := os.Open(s.path(WatchlistTableName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m := WatchlistMember{}
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			return err
+		}
+		s.watchlists = append(s.watchlists, m)
+	}
+	return scanner.Err()
+}
+
+func (s *FileSink) flushWatchlists() error {
+	if !s.watchlistsDirty {
+		return nil
+	}
+
+	err := s.rewrite(WatchlistTableName, func(enc *json.Encoder) error {
+		for _, m := range s.watchlists {
+			if err := enc.Encode(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.watchlistsDirty = false
+	return nil
+}
+
+func (s *FileSink) WatchlistNames(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadWatchlists(); err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, m := range s.watchlists {
+		if !seen[m.Watchlist] {
+			seen[m.Watchlist] = true
+			names = append(names, m.Watchlist)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *FileSink) ReadWatchlist(ctx context.Context, name string) ([]WatchlistMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadWatchlists(); err != nil {
+		return nil, err
+	}
+
+	ms := []WatchlistMember{}
+	for _, m := range s.watchlists {
+		if m.Watchlist == name {
+			ms = append(ms, m)
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Symbol < ms[j].Symbol })
+	return ms, nil
+}
+
+func (s *FileSink) AddToWatchlist(ctx context.Context, ms []WatchlistMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadWatchlists(); err != nil {
+		return err
+	}
+
+ADD_MEMBERS:
+	for _, m := range ms {
+		for i := range s.watchlists {
+			if s.watchlists[i].key() == m.key() {
+				s.watchlists[i].Class, s.watchlists[i].Intervals = m.Class, m.Intervals
+				continue ADD_MEMBERS
+			}
+		}
+		s.watchlists = append(s.watchlists, m)
+	}
+	s.watchlistsDirty = true
+	return nil
+}
+
+func (s *FileSink) RemoveFromWatchlist(ctx context.Context, name string, symbols []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadWatchlists(); err != nil {
+		return err
+	}
+
+	remove := map[watchlistKey]bool{}
+	for _, symbol := range symbols {
+		remove[watchlistKey{name, symbol}] = true
+	}
+	kept := []WatchlistMember{}
+	for _, m := range s.watchlists {
+		if !remove[m.key()] {
+			kept = append(kept, m)
+		}
+	}
+	s.watchlists = kept
+	s.watchlistsDirty = true
+	return nil
+}
+
+func (s *FileSink) TickRange(ctx context.Context, symbol, granularity string) (first, last time.Time, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = s.loadTicks(); err != nil {
+		return
+	}
+
+	for _, t := range s.ticks {
+		if t.Symbol != symbol || t.Granularity != granularity {
+			continue
+		}
+
+		if !ok || t.Timestamp.Before(first) {
+			first = t.Timestamp.Time
+		}
+		if !ok || t.Timestamp.After(last) {
+			last = t.Timestamp.Time
+		}
+		ok = true
+	}
+	return
+}
+
+func (s *FileSink) FirstTradeDate(ctx context.Context, symbol string) (date time.Time, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = s.loadTicks(); err != nil {
+		return
+	}
+
+	for _, t := range s.ticks {
+		if t.Symbol != symbol || t.FirstTradeDate.IsZero() {
+			continue
+		}
+		if !ok || t.FirstTradeDate.Before(date) {
+			date = t.FirstTradeDate.Time
+		}
+		ok = true
+	}
+	return
+}
+
+func (s *FileSink) ReadTicks(ctx context.Context, symbol, granularity string) ([]Tick, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadTicks(); err != nil {
+		return nil, err
+	}
+
+	ticks := []Tick{}
+	for _, t := range s.ticks {
+		if t.Symbol == symbol && t.Granularity == granularity {
+			t.localize()
+			ticks = append(ticks, t)
+		}
+	}
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i].Timestamp.Before(ticks[j].Timestamp.Time) })
+	return ticks, nil
+}
+
+func (s *FileSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range s.writers {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if err := s.flushWatchlists(); err != nil {
+		return err
+	}
+	return s.flushTicks()
+}
+
+func (s *FileSink) Close() error {
+	if err := s.Flush(context.Background()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for table, f := range s.files {
+		if err := f.Close(); err != nil {
+			return err
+		}
+		delete(s.files, table)
+		delete(s.writers, table)
+	}
+	return nil
+}
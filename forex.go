@@ -1,14 +1,26 @@
 package odbc
 
 import (
+	"context"
+	"strings"
+
 	"github.com/piquette/finance-go"
-	"github.com/piquette/finance-go/forex"
 )
 
 type ForexPair struct {
 	Quote
 }
 
+var forexAssetClass = AssetClass{
+	Name:      strings.ToLower(string(finance.QuoteTypeForexPair)),
+	Title:     "Forex",
+	QuoteType: finance.QuoteTypeForexPair,
+
+	Convert: NewAnonForexFromAPI,
+	NewAPI:  func() interface{} { return &finance.ForexPair{} },
+	Schema:  ForexPair{},
+}
+
 func NewAnonForexFromAPI(c interface{}) (p interface{}, ok bool) {
 	var apiForexPair *finance.ForexPair
 	apiForexPair, ok = c.(*finance.ForexPair)
@@ -21,7 +33,7 @@ func NewAnonForexFromAPI(c interface{}) (p interface{}, ok bool) {
 }
 
 func GetAnonForexFromAPI(symbol string) (interface{}, error) {
-	return forex.Get(symbol)
+	return DefaultProvider.GetAsset(context.Background(), finance.QuoteTypeForexPair, symbol)
 }
 
 func NewForexFromAPI(e *finance.ForexPair) (fp ForexPair, ok bool) {
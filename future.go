@@ -1,20 +1,33 @@
 package odbc
 
 import (
+	"context"
+	"strings"
+
 	"github.com/piquette/finance-go"
-	"github.com/piquette/finance-go/future"
+	"github.com/shopspring/decimal"
 )
 
 type Future struct {
 	Quote
 
-	UnderlyingSymbol         string  `db:"underlying_symbol" json:"underlying_symbol"`
-	OpenInterest             int     `db:"open_interest" json:"open_interest"`
-	ExpireDate               int     `db:"expire_date" json:"expire_date"`
-	Strike                   float64 `db:"strike" json:"strike"`
-	UnderlyingExchangeSymbol string  `db:"underlying_exchange_symbol" json:"underlying_exchange_symbol"`
-	HeadSymbolAsString       string  `db:"head_symbol_as_string" json:"head_symbol_as_string"`
-	IsContractSymbol         bool    `db:"is_contract_symbol" json:"is_contract_symbol"`
+	UnderlyingSymbol         string          `db:"underlying_symbol" json:"underlying_symbol"`
+	OpenInterest             int             `db:"open_interest" json:"open_interest"`
+	ExpireDate               Timestamp       `db:"expire_date" json:"expire_date"`
+	Strike                   decimal.Decimal `db:"strike" json:"strike"`
+	UnderlyingExchangeSymbol string          `db:"underlying_exchange_symbol" json:"underlying_exchange_symbol"`
+	HeadSymbolAsString       string          `db:"head_symbol_as_string" json:"head_symbol_as_string"`
+	IsContractSymbol         bool            `db:"is_contract_symbol" json:"is_contract_symbol"`
+}
+
+var futureAssetClass = AssetClass{
+	Name:      strings.ToLower(string(finance.QuoteTypeFuture)),
+	Title:     "Future",
+	QuoteType: finance.QuoteTypeFuture,
+
+	Convert: NewAnonFutureFromAPI,
+	NewAPI:  func() interface{} { return &finance.Future{} },
+	Schema:  Future{},
 }
 
 func NewAnonFutureFromAPI(c interface{}) (f interface{}, ok bool) {
@@ -29,7 +42,7 @@ func NewAnonFutureFromAPI(c interface{}) (f interface{}, ok bool) {
 }
 
 func GetAnonFutureFromAPI(symbol string) (interface{}, error) {
-	return future.Get(symbol)
+	return DefaultProvider.GetAsset(context.Background(), finance.QuoteTypeFuture, symbol)
 }
 
 func NewFutureFromAPI(e *finance.Future) (f Future, ok bool) {
@@ -43,8 +56,8 @@ func NewFutureFromAPI(e *finance.Future) (f Future, ok bool) {
 
 		UnderlyingSymbol:         e.UnderlyingSymbol,
 		OpenInterest:             e.OpenInterest,
-		ExpireDate:               e.ExpireDate,
-		Strike:                   e.Strike,
+		ExpireDate:               NewTimestamp(e.ExpireDate, quoteLocation(&e.Quote)),
+		Strike:                   NewPrice(e.Strike, e.CurrencyID),
 		UnderlyingExchangeSymbol: e.UnderlyingExchangeSymbol,
 		HeadSymbolAsString:       e.HeadSymbolAsString,
 		IsContractSymbol:         e.IsContractSymbol,
@@ -0,0 +1,118 @@
+package odbc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jakoblorz/finance-odbc/calendar"
+	"github.com/piquette/finance-go/datetime"
+)
+
+// Gap is a run of consecutive bars missing from a stored series. Start
+// is the first missing bar, End the first bar after the gap or the end
+// of the checked range.
+type Gap struct {
+	Symbol      string
+	Granularity string
+	Start       time.Time
+	End         time.Time
+	Bars        int
+}
+
+func (g Gap) String() string {
+	return fmt.Sprintf("%s %s: %d bar(s) missing from %s to %s", g.Symbol, g.Granularity, g.Bars, g.Start.Format(time.RFC3339), g.End.Format(time.RFC3339))
+}
+
+// Request returns the TickRequest that downloads the bars of g again.
+func (g Gap) Request() TickRequest {
+	return TickRequest{
+		Symbol:   g.Symbol,
+		Interval: datetime.Interval(g.Granularity),
+		Start:    g.Start,
+		End:      g.End,
+	}
+}
+
+func tradingHoursOfCalendar(c *calendar.Calendar) TradingHours {
+	return TradingHours{
+		Location:     c.Location,
+		AllWeek:      c.AllWeek,
+		PreStart:     c.PreOpen,
+		RegularStart: c.Open,
+		RegularEnd:   c.Close,
+		PostEnd:      c.PostClose,
+	}
+}
+
+// ExpectedBars returns the start of every bar of granularity that the
+// regular sessions of c between from and to should produce.
+func ExpectedBars(c *calendar.Calendar, granularity string, from, to time.Time) ([]time.Time, error) {
+	p, err := parseBarPeriod(granularity)
+	if err != nil {
+		return nil, err
+	}
+	h := tradingHoursOfCalendar(c)
+
+	bars := []time.Time{}
+	add := func(t time.Time) {
+		b := bucket(h, p, t)
+		if b.Before(from) || !b.Before(to) {
+			return
+		}
+		if n := len(bars); n == 0 || !bars[n-1].Equal(b) {
+			bars = append(bars, b)
+		}
+	}
+	for _, s := range c.Sessions(from, to) {
+		if p.intraday == 0 {
+			add(s.Open)
+			continue
+		}
+		for t := s.Open; t.Before(s.Close); t = t.Add(p.intraday) {
+			add(t)
+		}
+	}
+	return bars, nil
+}
+
+// FindGaps compares the stored ticks of symbol at granularity against
+// the bars the calendar c expects between from and to and returns the
+// missing runs. Stored bars are matched to the expected ones by the bar
+// they fall into, so differing timestamp conventions do not count as
+// gaps.
+func FindGaps(c *calendar.Calendar, symbol, granularity string, ticks []Tick, from, to time.Time) ([]Gap, error) {
+	expected, err := ExpectedBars(c, granularity, from, to)
+	if err != nil {
+		return nil, err
+	}
+	p, _ := parseBarPeriod(granularity)
+	h := tradingHoursOfCalendar(c)
+
+	stored := map[int64]bool{}
+	for _, t := range ticks {
+		stored[bucket(h, p, t.Timestamp.Time).Unix()] = true
+	}
+
+	gaps := []Gap{}
+	var gap *Gap
+	for _, b := range expected {
+		if stored[b.Unix()] {
+			if gap != nil {
+				gap.End = b
+				gaps = append(gaps, *gap)
+				gap = nil
+			}
+			continue
+		}
+
+		if gap == nil {
+			gap = &Gap{Symbol: symbol, Granularity: granularity, Start: b}
+		}
+		gap.Bars++
+	}
+	if gap != nil {
+		gap.End = to
+		gaps = append(gaps, *gap)
+	}
+	return gaps, nil
+}
@@ -0,0 +1,42 @@
+package odbc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jakoblorz/finance-odbc/calendar"
+)
+
+func TestFindGaps(t *testing.T) {
+	ny := calendar.NYSE.Location
+	day := func(d int) time.Time { return time.Date(2024, time.July, d, 9, 30, 0, 0, ny) }
+
+	ticks := []Tick{}
+	for _, d := range []int{1, 2, 3, 5, 10, 11, 12} {
+		ticks = append(ticks, Tick{Symbol: "AAPL", Granularity: "1d", Timestamp: Timestamp{day(d)}})
+	}
+
+	from, to := time.Date(2024, time.July, 1, 0, 0, 0, 0, ny), time.Date(2024, time.July, 13, 0, 0, 0, 0, ny)
+	gaps, err := FindGaps(calendar.NYSE, "AAPL", "1d", ticks, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gaps) != 1 {
+		t.Fatalf("got gaps %v, want one", gaps)
+	}
+	if g := gaps[0]; g.Bars != 2 || !g.Start.Equal(day(8)) || !g.End.Equal(day(10)) {
+		t.Errorf("unexpected gap %s", g)
+	}
+	if r := gaps[0].Request(); r.Symbol != "AAPL" || r.Interval != "1d" || !r.Start.Equal(day(8)) {
+		t.Errorf("unexpected request %+v", r)
+	}
+
+	// July 3rd closes early at 13:00.
+	bars, err := ExpectedBars(calendar.NYSE, "1h", day(3).Add(-time.Hour), day(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 4 || !bars[0].Equal(day(3)) {
+		t.Errorf("expected hourly bars %v", bars)
+	}
+}
@@ -1,14 +1,27 @@
 package odbc
 
 import (
+	"context"
+	"strings"
+
 	"github.com/piquette/finance-go"
-	"github.com/piquette/finance-go/index"
 )
 
 type Index struct {
 	Quote
 }
 
+var indexAssetClass = AssetClass{
+	Name:      strings.ToLower(string(finance.QuoteTypeIndex)),
+	Title:     "Index",
+	QuoteType: finance.QuoteTypeIndex,
+	TableName: "indices",
+
+	Convert: NewAnonIndexFromAPI,
+	NewAPI:  func() interface{} { return &finance.Index{} },
+	Schema:  Index{},
+}
+
 func NewAnonIndexFromAPI(c interface{}) (i interface{}, ok bool) {
 	var apiIndex *finance.Index
 	apiIndex, ok = c.(*finance.Index)
@@ -21,7 +34,7 @@ func NewAnonIndexFromAPI(c interface{}) (i interface{}, ok bool) {
 }
 
 func GetAnonIndexFromAPI(symbol string) (interface{}, error) {
-	return index.Get(symbol)
+	return DefaultProvider.GetAsset(context.Background(), finance.QuoteTypeIndex, symbol)
 }
 
 func NewIndexFromAPI(e *finance.Index) (i Index, ok bool) {
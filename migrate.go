@@ -0,0 +1,426 @@
+package odbc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SchemaVersionTableName is the table recording every migration applied
+// to a database.
+const SchemaVersionTableName = "schema_version"
+
+// SchemaVersion is a row of the schema version table. Each applied
+// batch of migrations gets the next version.
+type SchemaVersion struct {
+	Version     int       `db:"version" json:"version"`
+	AppliedAt   time.Time `db:"applied_at" json:"applied_at"`
+	Description string    `db:"description" json:"description"`
+}
+
+// Migration is a change bringing a live table in line with the struct
+// stored in it: creating the table, adding a column, converting a
+// column older versions stored differently or adding an index. Adding
+// a natural key to a table holding rows that share it also deletes all
+// but the newest of them, and the bars older versions stored under
+// query ranges are deleted; Deletes then describes the rows dropped.
+type Migration struct {
+	Table       string
+	Description string
+	Statements  []string
+	Deletes     []string
+
+	// Rewrites is set on the conversions of stored values, which may
+	// rebuild the whole table.
+	Rewrites bool
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%s: %s", m.Table, m.Description)
+}
+
+// Destructive reports whether m deletes rows.
+func (m Migration) Destructive() bool {
+	return len(m.Deletes) > 0
+}
+
+// CheckAdditive fails if any of ms is destructive or rewrites stored
+// values. Those are only to be applied by an explicit migration, never
+// on the way to a write.
+func CheckAdditive(ms []Migration) error {
+	for _, m := range ms {
+		if m.Destructive() || m.Rewrites {
+			return fmt.Errorf("%s: %s; review it with migrate -dry-run and apply it with migrate", m.Table, m.Description)
+		}
+	}
+	return nil
+}
+
+// Migrator is implemented by sinks with a schema that can fall behind
+// the structs. PlanMigrations diffs the `db` tags of StoredTables
+// against the live tables; Migrate applies the migrations and returns
+// the new schema version.
+type Migrator interface {
+	SchemaVersion(ctx context.Context) (int, error)
+	PlanMigrations(ctx context.Context) ([]Migration, error)
+	Migrate(ctx context.Context, ms []Migration) (int, error)
+}
+
+// NaturalKeys returns the key columns of the tables that enforce one.
+func NaturalKeys() map[string][]string {
+	return map[string][]string{
+		TickTableName:            TickKeyColumns,
+		TickValidRangeTableName:  TickValidRangeKeyColumns,
+		CorporateActionTableName: CorporateActionKeyColumns,
+		WatchlistTableName:       WatchlistKeyColumns,
+	}
+}
+
+// dialect holds what differs between the databases a schema is migrated
+// in.
+type dialect struct {
+	columnType func(reflect.Type) (string, error)
+
+	// columns returns the declared type of every column of table, or nil
+	// if there is no such table.
+	columns func(ctx context.Context, db *sqlx.DB, table string) (map[string]string, error)
+	indexes func(ctx context.Context, db *sqlx.DB, table string) (map[string]bool, error)
+
+	// convertUnix returns the statement converting the Unix seconds older
+	// versions stored in column, or "" if there are none.
+	convertUnix func(ctx context.Context, db *sqlx.DB, table, column, columnType string) (string, error)
+
+	// floating reports whether columnType is a floating point type, which
+	// older versions stored decimals as; convertDecimals returns the
+	// statements converting columns to the decimal column type.
+	floating        func(columnType string) bool
+	convertDecimals func(ctx context.Context, db *sqlx.DB, table string, columns []string) ([]string, error)
+
+	// dedupe returns the statement deleting all but one row of each key.
+	dedupe func(table string, columns []string) string
+}
+
+func naturalKeyIndexName(table string) string {
+	return table + "_natural_key"
+}
+
+func createKeyIndexStatement(table string, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteIdentifier(c)
+	}
+	return fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s)",
+		quoteIdentifier(naturalKeyIndexName(table)),
+		quoteIdentifier(table),
+		strings.Join(quoted, ", "),
+	)
+}
+
+// createMissingTable creates table with its natural key, unless it
+// exists.
+func createMissingTable(ctx context.Context, db *sqlx.DB, d dialect, table string, schema interface{}) error {
+	live, err := d.columns(ctx, db, table)
+	if err != nil || live != nil {
+		return err
+	}
+
+	stmt, err := createTableStatement(table, schema, d.columnType)
+	if err != nil {
+		return err
+	}
+	stmts := []string{stmt}
+	if key, ok := NaturalKeys()[table]; ok {
+		stmts = append(stmts, createKeyIndexStatement(table, key))
+	}
+	return inTx(ctx, db, func(tx *sqlx.Tx) error {
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// zeroDefault returns the SQL literal of the zero value of t. Added
+// columns are filled with it, as the structs cannot scan the NULL they
+// would otherwise hold in existing rows; ok is false for Timestamp,
+// which stores its zero value as NULL.
+func zeroDefault(t reflect.Type) (literal string, ok bool) {
+	switch t {
+	case timestampType:
+		return "", false
+	case decimalType:
+		return "'0'", true
+	case timeType:
+		return "'0001-01-01 00:00:00+00:00'", true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "''", true
+	case reflect.Bool:
+		return "FALSE", true
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8, reflect.Float64, reflect.Float32:
+		return "0", true
+	}
+	return "", false
+}
+
+// planMigrations diffs tables, keyed by name with a zero value of the
+// stored struct, against the live schema of db.
+func planMigrations(ctx context.Context, db *sqlx.DB, d dialect, tables map[string]interface{}) ([]Migration, error) {
+	names := []string{}
+	for table := range tables {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+
+	ms := []Migration{}
+	for _, table := range names {
+		schema := tables[table]
+		live, err := d.columns(ctx, db, table)
+		if err != nil {
+			return nil, err
+		}
+
+		if live == nil {
+			stmt, err := createTableStatement(table, schema, d.columnType)
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, Migration{Table: table, Description: "create table", Statements: []string{stmt}})
+			if key, ok := NaturalKeys()[table]; ok {
+				ms = append(ms, Migration{Table: table, Description: "add natural key", Statements: []string{createKeyIndexStatement(table, key)}})
+			}
+			continue
+		}
+
+		// Converting decimals may rebuild the table from its live columns,
+		// so it comes before any column is added.
+		floats := []string{}
+		for _, c := range ColumnsOf(schema) {
+			if have, ok := live[c.Name]; ok && c.Type == decimalType && d.floating(have) {
+				floats = append(floats, c.Name)
+			}
+		}
+		if len(floats) > 0 {
+			stmts, err := d.convertDecimals(ctx, db, table, floats)
+			if err != nil {
+				return nil, err
+			}
+			t, _ := d.columnType(decimalType)
+			ms = append(ms, Migration{
+				Table:       table,
+				Description: fmt.Sprintf("convert columns %s from floating point to %s", strings.Join(floats, ", "), t),
+				Statements:  stmts,
+				Rewrites:    true,
+			})
+		}
+
+		for _, c := range ColumnsOf(schema) {
+			t, err := d.columnType(c.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %s", table, c.Name, err)
+			}
+
+			have, ok := live[c.Name]
+			if !ok {
+				if zero, ok := zeroDefault(c.Type); ok {
+					t = fmt.Sprintf("%s NOT NULL DEFAULT %s", t, zero)
+				}
+				ms = append(ms, Migration{
+					Table:       table,
+					Description: fmt.Sprintf("add column %s %s", c.Name, t),
+					Statements:  []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", quoteIdentifier(table), quoteIdentifier(c.Name), t)},
+				})
+				continue
+			}
+			if c.Type != timestampType {
+				continue
+			}
+
+			stmt, err := d.convertUnix(ctx, db, table, c.Name, have)
+			if err != nil {
+				return nil, err
+			}
+			if stmt != "" {
+				ms = append(ms, Migration{
+					Table:       table,
+					Description: fmt.Sprintf("convert column %s from Unix seconds to %s", c.Name, t),
+					Statements:  []string{stmt},
+					Rewrites:    true,
+				})
+			}
+		}
+
+		if table == TickTableName {
+			m, err := queryRangeTicks(ctx, db)
+			if err != nil {
+				return nil, err
+			}
+			if m.Destructive() {
+				ms = append(ms, m)
+			}
+		}
+
+		key, ok := NaturalKeys()[table]
+		if !ok {
+			continue
+		}
+		indexes, err := d.indexes(ctx, db, table)
+		if err != nil {
+			return nil, err
+		}
+		if indexes[naturalKeyIndexName(table)] {
+			continue
+		}
+		m := Migration{
+			Table:       table,
+			Description: fmt.Sprintf("add natural key (%s)", strings.Join(key, ", ")),
+			Statements:  []string{createKeyIndexStatement(table, key)},
+		}
+		if m.Deletes, err = duplicateKeys(ctx, db, table, key); err != nil {
+			return nil, err
+		}
+		if m.Destructive() {
+			m.Description += fmt.Sprintf(", dropping the duplicate rows of %d key(s)", len(m.Deletes))
+			m.Statements = append([]string{d.dedupe(table, key)}, m.Statements...)
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// queryRangeTicks plans deleting the bars older versions stored once
+// per query range, labelled 6mo, 1y, ... or max although they are bars
+// of another interval. Bars resampled into 6mo are derived anew by
+// resample.
+func queryRangeTicks(ctx context.Context, db *sqlx.DB) (Migration, error) {
+	ranges := make([]string, len(QueryRanges))
+	for i, r := range QueryRanges {
+		ranges[i] = fmt.Sprintf("'%s'", r)
+	}
+	in := fmt.Sprintf(`"granularity" IN (%s)`, strings.Join(ranges, ", "))
+
+	counts := []struct {
+		Granularity string `db:"granularity"`
+		Count       int    `db:"count"`
+	}{}
+	err := db.SelectContext(ctx, &counts, fmt.Sprintf(
+		`SELECT "granularity", COUNT(*) AS "count" FROM %s WHERE %s GROUP BY "granularity" ORDER BY "granularity"`,
+		quoteIdentifier(TickTableName), in,
+	))
+	if err != nil {
+		return Migration{}, err
+	}
+
+	m := Migration{Table: TickTableName}
+	labels := []string{}
+	for _, c := range counts {
+		labels = append(labels, c.Granularity)
+		m.Deletes = append(m.Deletes, fmt.Sprintf("granularity=%s: %d row(s)", c.Granularity, c.Count))
+	}
+	m.Description = fmt.Sprintf("delete the bars stored under the query ranges %s", strings.Join(labels, ", "))
+	m.Statements = []string{fmt.Sprintf("DELETE FROM %s WHERE %s", quoteIdentifier(TickTableName), in)}
+	return m, nil
+}
+
+// duplicateKeys describes the rows of table that share their key with
+// a newer row, which have to be dropped to add the natural key.
+func duplicateKeys(ctx context.Context, db *sqlx.DB, table string, key []string) ([]string, error) {
+	quoted := make([]string, len(key))
+	for i, c := range key {
+		quoted[i] = quoteIdentifier(c)
+	}
+	rows, err := db.QueryxContext(ctx, fmt.Sprintf(
+		`SELECT %[2]s, COUNT(*) - 1 FROM %[1]s GROUP BY %[2]s HAVING COUNT(*) > 1 ORDER BY %[2]s`,
+		quoteIdentifier(table), strings.Join(quoted, ", "),
+	))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ds := []string{}
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+
+		pairs := make([]string, len(key))
+		for i, c := range key {
+			pairs[i] = fmt.Sprintf("%s=%v", c, values[i])
+		}
+		ds = append(ds, fmt.Sprintf("%s: %v duplicate row(s)", strings.Join(pairs, ", "), values[len(key)]))
+	}
+	return ds, rows.Err()
+}
+
+func schemaVersion(ctx context.Context, db *sqlx.DB, d dialect) (int, error) {
+	live, err := d.columns(ctx, db, SchemaVersionTableName)
+	if err != nil || live == nil {
+		return 0, err
+	}
+
+	var version int
+	err = db.GetContext(ctx, &version, fmt.Sprintf(`SELECT COALESCE(MAX("version"), 0) FROM %s`, quoteIdentifier(SchemaVersionTableName)))
+	return version, err
+}
+
+// migrate applies ms in a single transaction and records them as the
+// next schema version.
+func migrate(ctx context.Context, db *sqlx.DB, d dialect, ms []Migration) (int, error) {
+	if len(ms) == 0 {
+		return schemaVersion(ctx, db, d)
+	}
+
+	stmt, err := createTableStatement(SchemaVersionTableName, SchemaVersion{}, d.columnType)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return 0, err
+	}
+	version, err := schemaVersion(ctx, db, d)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	descriptions := make([]string, len(ms))
+	for i, m := range ms {
+		for _, stmt := range m.Statements {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return 0, fmt.Errorf("migrating %s: %s", m, err)
+			}
+		}
+		descriptions[i] = m.String()
+	}
+
+	version++
+	_, err = tx.ExecContext(ctx, tx.Rebind(fmt.Sprintf(
+		`INSERT INTO %s ("version", "applied_at", "description") VALUES (?, ?, ?)`,
+		quoteIdentifier(SchemaVersionTableName),
+	)), version, time.Now().UTC(), strings.Join(descriptions, "\n"))
+	if err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
@@ -0,0 +1,269 @@
+package odbc
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/jakoblorz/dynsql/lib/go-sqlite3"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+func openSQLite(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Connect("dyn-sqlite3", filepath.Join(t.TempDir(), "finance.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// createLegacyTicks creates the ticks table as written before sessions,
+// natural keys and time.Time timestamps, holding one bar of AAPL at 1d
+// per Unix timestamp given.
+func createLegacyTicks(t *testing.T, db *sqlx.DB, timestamps ...int) {
+	defs, names, values := []string{}, []string{}, []string{}
+	for _, c := range ColumnsOf(Tick{}) {
+		if c.Name == "session" {
+			continue
+		}
+		typ, _ := SQLiteColumnType(c.Type)
+		value := "''"
+		switch {
+		case c.Name == "symbol":
+			value = "'AAPL'"
+		case c.Name == "granularity":
+			value = "'1d'"
+		case c.Type == timestampType:
+			typ, value = "INTEGER", "%[1]d"
+		case c.Type == decimalType:
+			value = "'1.5'"
+		case c.Type == timeType:
+			value = "'2020-09-13 12:26:40+00:00'"
+		case typ == "INTEGER":
+			value = "0"
+		}
+		defs = append(defs, fmt.Sprintf("%s %s", quoteIdentifier(c.Name), typ))
+		names = append(names, quoteIdentifier(c.Name))
+		values = append(values, value)
+	}
+
+	stmts := []string{fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdentifier(TickTableName), strings.Join(defs, ", "))}
+	for _, ts := range timestamps {
+		stmts = append(stmts, fmt.Sprintf(
+			fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdentifier(TickTableName), strings.Join(names, ", "), strings.Join(values, ", ")),
+			ts,
+		))
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %s", stmt, err)
+		}
+	}
+}
+
+func TestMigrateLegacyTicks(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	createLegacyTicks(t, db, 1600000000, 1600086400)
+	s := NewSQLiteSink(db)
+
+	ms, err := s.PlanMigrations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planned := []string{}
+	for _, m := range ms {
+		if m.Table == TickTableName {
+			planned = append(planned, m.Description)
+		}
+	}
+	want := []string{}
+	for _, c := range ColumnsOf(Tick{}) {
+		if c.Type == timestampType {
+			want = append(want, fmt.Sprintf("convert column %s from Unix seconds to DATETIME", c.Name))
+		}
+	}
+	want = append(want,
+		"add column session TEXT NOT NULL DEFAULT ''",
+		"add natural key (symbol, granularity, timestamp)",
+	)
+	if strings.Join(planned, "\n") != strings.Join(want, "\n") {
+		t.Errorf("planned\n%s\nwant\n%s", strings.Join(planned, "\n"), strings.Join(want, "\n"))
+	}
+	if err := CheckAdditive(ms); err == nil {
+		t.Errorf("converting Unix timestamps passes as additive")
+	}
+	if version, err := s.Migrate(ctx, ms); err != nil || version != 1 {
+		t.Fatalf("migrating to version %d failed: %v", version, err)
+	}
+
+	ticks, err := s.ReadTicks(ctx, "AAPL", "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ticks) != 2 || ticks[0].Timestamp.Unix() != 1600000000 || !ticks[0].Close.Equal(decimal.RequireFromString("1.5")) {
+		t.Fatalf("read %+v from the migrated table", ticks)
+	}
+
+	revised, added := ticks[1], ticks[1]
+	revised.Close = decimal.RequireFromString("1.75")
+	added.Timestamp = Timestamp{added.Timestamp.Add(24 * time.Hour)}
+	stats, err := s.WriteTicks(ctx, []Tick{ticks[0], revised, added}, Upsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (WriteStats{Inserted: 1, Updated: 1, Unchanged: 1}) {
+		t.Errorf("writing to the migrated table: %s", stats)
+	}
+	if ticks, err = s.ReadTicks(ctx, "AAPL", "1d"); err != nil || len(ticks) != 3 || !ticks[1].Close.Equal(revised.Close) {
+		t.Errorf("read %d ticks back after writing, %v", len(ticks), err)
+	}
+
+	if ms, err = s.PlanMigrations(ctx); err != nil || len(ms) != 0 {
+		t.Errorf("still planning %v after migrating, %v", ms, err)
+	}
+}
+
+func TestMigrateDuplicateTicks(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	createLegacyTicks(t, db, 1600000000, 1600000000, 1600086400)
+	s := NewSQLiteSink(db)
+
+	count := func() (n int) {
+		if err := db.Get(&n, `SELECT COUNT(*) FROM "ticks"`); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	// Using the table leaves migrating it to Migrate.
+	s.WriteTicks(ctx, nil, KeepStored)
+	if n := count(); n != 3 {
+		t.Fatalf("writing dropped %d rows", 3-n)
+	}
+	if v, err := s.SchemaVersion(ctx); err != nil || v != 0 {
+		t.Errorf("writing migrated to version %d, %v", v, err)
+	}
+
+	ms, err := s.PlanMigrations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckAdditive(ms); err == nil {
+		t.Errorf("dropping duplicates passes as additive")
+	}
+	deletes := []string{}
+	for _, m := range ms {
+		deletes = append(deletes, m.Deletes...)
+	}
+	if want := "symbol=AAPL, granularity=1d, timestamp=1600000000: 1 duplicate row(s)"; len(deletes) != 1 || deletes[0] != want {
+		t.Errorf("planned to delete %v, want %s", deletes, want)
+	}
+
+	if _, err := s.Migrate(ctx, ms); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 2 {
+		t.Errorf("%d rows left after migrating, want 2", n)
+	}
+}
+
+func TestMigrateFloatPrices(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	// equities as stored before prices were decimals
+	defs := []string{}
+	for _, c := range ColumnsOf(Equity{}) {
+		typ, _ := SQLiteColumnType(c.Type)
+		if c.Type == decimalType {
+			typ = "REAL"
+		}
+		defs = append(defs, fmt.Sprintf("%s %s", quoteIdentifier(c.Name), typ))
+	}
+	db.MustExec(fmt.Sprintf(`CREATE TABLE "equity" (%s)`, strings.Join(defs, ", ")))
+	db.MustExec(`INSERT INTO "equity" ("symbol", "bid", "book_value") VALUES ('AAPL', 0.1, 3.3)`)
+	db.MustExec(`CREATE INDEX "equity_symbol" ON "equity" ("symbol")`)
+
+	s := NewSQLiteSink(db)
+	ms, err := s.PlanMigrations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Migrate(ctx, ms); err != nil {
+		t.Fatal(err)
+	}
+
+	e := Equity{}
+	e.Symbol, e.Bid = "MSFT", decimal.RequireFromString("0.30000000000000000001")
+	if err := s.WriteQuotes(ctx, "equity", []interface{}{e}); err != nil {
+		t.Fatal(err)
+	}
+	rows := []struct {
+		Symbol string `db:"symbol"`
+		Bid    string `db:"bid"`
+		Type   string `db:"type"`
+	}{}
+	if err := db.Select(&rows, `SELECT "symbol", "bid", typeof("bid") AS "type" FROM "equity" ORDER BY "symbol"`); err != nil {
+		t.Fatal(err)
+	}
+	want := "[{AAPL 0.1 text} {MSFT 0.30000000000000000001 text}]"
+	if got := fmt.Sprint(rows); got != want {
+		t.Errorf("stored %s, want %s", got, want)
+	}
+	var bookValue string
+	if err := db.Get(&bookValue, `SELECT "book_value" FROM "equity" WHERE "symbol" = 'AAPL'`); err != nil || bookValue != "3.3" {
+		t.Errorf("book value %q after migrating, %v", bookValue, err)
+	}
+	var indexed bool
+	if err := db.Get(&indexed, `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE "name" = 'equity_symbol')`); err != nil || !indexed {
+		t.Errorf("index of the rebuilt table is gone, %v", err)
+	}
+	if ms, err = s.PlanMigrations(ctx); err != nil || len(ms) != 0 {
+		t.Errorf("still planning %v after migrating, %v", ms, err)
+	}
+}
+
+func TestMigrateQueryRangeTicks(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	// Older versions stored a copy of the bar per query range.
+	createLegacyTicks(t, db, 1600000000, 1600000000, 1600000000, 1600086400)
+	db.MustExec(`UPDATE "ticks" SET "granularity" = '1y' WHERE rowid = 2`)
+	db.MustExec(`UPDATE "ticks" SET "granularity" = 'max' WHERE rowid IN (3, 4)`)
+	s := NewSQLiteSink(db)
+
+	ms, err := s.PlanMigrations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletes := []string{}
+	for _, m := range ms {
+		if strings.Contains(m.Description, "query ranges 1y, max") {
+			if err := CheckAdditive([]Migration{m}); err == nil {
+				t.Errorf("%s passes as additive", m)
+			}
+		}
+		deletes = append(deletes, m.Deletes...)
+	}
+	if got, want := strings.Join(deletes, "; "), "granularity=1y: 1 row(s); granularity=max: 2 row(s)"; got != want {
+		t.Errorf("planned to delete %s, want %s", got, want)
+	}
+
+	if _, err := s.Migrate(ctx, ms); err != nil {
+		t.Fatal(err)
+	}
+	granularities := []string{}
+	if err := db.Select(&granularities, `SELECT "granularity" FROM "ticks"`); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(granularities) != "[1d]" {
+		t.Errorf("kept bars at %v after migrating, want only the 1d bar", granularities)
+	}
+}
@@ -0,0 +1,50 @@
+package odbc
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultPricePrecision is the number of decimal places prices are
+// rounded to unless their currency was given a precision with
+// SetCurrencyPrecision. It is generous enough for sub-cent crypto
+// prices and only cuts off the binary noise of the API's floats.
+const DefaultPricePrecision = 8
+
+var (
+	currencyPrecisionMu sync.RWMutex
+	currencyPrecision   = map[string]int32{}
+)
+
+// SetCurrencyPrecision makes prices in currency round to places decimal
+// places.
+func SetCurrencyPrecision(currency string, places int32) {
+	currencyPrecisionMu.Lock()
+	defer currencyPrecisionMu.Unlock()
+
+	currencyPrecision[strings.ToUpper(currency)] = places
+}
+
+// CurrencyPrecision returns the number of decimal places prices in
+// currency are rounded to.
+func CurrencyPrecision(currency string) int32 {
+	currencyPrecisionMu.RLock()
+	defer currencyPrecisionMu.RUnlock()
+
+	if places, ok := currencyPrecision[strings.ToUpper(currency)]; ok {
+		return places
+	}
+	return DefaultPricePrecision
+}
+
+// NewPrice converts a price reported by the API in currency.
+func NewPrice(f float64, currency string) decimal.Decimal {
+	return decimal.NewFromFloat(f).Round(CurrencyPrecision(currency))
+}
+
+// NewRatio converts a percentage, ratio or return reported by the API.
+func NewRatio(f float64) decimal.Decimal {
+	return decimal.NewFromFloat(f)
+}
@@ -0,0 +1,24 @@
+package odbc
+
+import (
+	"testing"
+
+	"github.com/piquette/finance-go"
+)
+
+func TestNewPrice(t *testing.T) {
+	if p := NewPrice(0.1+0.2, "USD"); p.String() != "0.3" {
+		t.Errorf("NewPrice(0.1+0.2) = %s, want 0.3", p)
+	}
+
+	SetCurrencyPrecision("jpy", 0)
+	defer SetCurrencyPrecision("JPY", DefaultPricePrecision)
+	if p := NewPrice(151.73, "JPY"); p.String() != "152" {
+		t.Errorf("NewPrice(151.73, JPY) = %s, want 152", p)
+	}
+
+	q := NewQuoteFromAPI(&finance.Quote{CurrencyID: "USD", Bid: 189.99999999999997, RegularMarketChangePercent: 1.25})
+	if q.Bid.String() != "190" || q.RegularMarketChangePercent.String() != "1.25" {
+		t.Errorf("converted bid %s and change %s%%", q.Bid, q.RegularMarketChangePercent)
+	}
+}
@@ -1,16 +1,29 @@
 package odbc
 
 import (
+	"context"
+	"strings"
+
 	"github.com/piquette/finance-go"
-	"github.com/piquette/finance-go/mutualfund"
+	"github.com/shopspring/decimal"
 )
 
 type MutualFund struct {
 	Quote
 
-	YTDReturn                    float64 `db:"ytd_return" json:"ytd_return"`
-	TrailingThreeMonthReturns    float64 `db:"trailing_three_month_returns" json:"trailing_three_month_returns"`
-	TrailingThreeMonthNavReturns float64 `db:"trailing_three_month_nav_returns" json:"trailing_three_month_nav_returns"`
+	YTDReturn                    decimal.Decimal `db:"ytd_return" json:"ytd_return"`
+	TrailingThreeMonthReturns    decimal.Decimal `db:"trailing_three_month_returns" json:"trailing_three_month_returns"`
+	TrailingThreeMonthNavReturns decimal.Decimal `db:"trailing_three_month_nav_returns" json:"trailing_three_month_nav_returns"`
+}
+
+var mutualFundAssetClass = AssetClass{
+	Name:      strings.ToLower(string(finance.QuoteTypeMutualFund)),
+	Title:     "Mutual Fund",
+	QuoteType: finance.QuoteTypeMutualFund,
+
+	Convert: NewAnonMutualFundFromAPI,
+	NewAPI:  func() interface{} { return &finance.MutualFund{} },
+	Schema:  MutualFund{},
 }
 
 func NewAnonMutualFundFromAPI(c interface{}) (m interface{}, ok bool) {
@@ -25,7 +38,7 @@ func NewAnonMutualFundFromAPI(c interface{}) (m interface{}, ok bool) {
 }
 
 func GetAnonMutualFundFromAPI(symbol string) (interface{}, error) {
-	return mutualfund.Get(symbol)
+	return DefaultProvider.GetAsset(context.Background(), finance.QuoteTypeMutualFund, symbol)
 }
 
 func NewMutualFundFromAPI(e *finance.MutualFund) (m MutualFund, ok bool) {
@@ -37,9 +50,9 @@ func NewMutualFundFromAPI(e *finance.MutualFund) (m MutualFund, ok bool) {
 	m = MutualFund{
 		Quote: NewQuoteFromAPI(&e.Quote),
 
-		YTDReturn:                    e.YTDReturn,
-		TrailingThreeMonthReturns:    e.TrailingThreeMonthReturns,
-		TrailingThreeMonthNavReturns: e.TrailingThreeMonthNavReturns,
+		YTDReturn:                    NewRatio(e.YTDReturn),
+		TrailingThreeMonthReturns:    NewRatio(e.TrailingThreeMonthReturns),
+		TrailingThreeMonthNavReturns: NewRatio(e.TrailingThreeMonthNavReturns),
 	}
 	return
 }
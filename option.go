@@ -1,8 +1,11 @@
 package odbc
 
 import (
+	"context"
+	"strings"
+
 	"github.com/piquette/finance-go"
-	"github.com/piquette/finance-go/option"
+	"github.com/shopspring/decimal"
 )
 
 type Option struct {
@@ -11,9 +14,19 @@ type Option struct {
 	UnderlyingSymbol         string `db:"underlying_symbol" json:"underlying_symbol"`
 	UnderlyingExchangeSymbol string `db:"underlying_exchange_symbol" json:"underlying_exchange_symbol"`
 
-	OpenInterest int     `db:"open_interest" json:"open_interest"`
-	ExpireDate   int     `db:"expire_date" json:"expire_date"`
-	Strike       float64 `db:"strike" json:"strike"`
+	OpenInterest int             `db:"open_interest" json:"open_interest"`
+	ExpireDate   Timestamp       `db:"expire_date" json:"expire_date"`
+	Strike       decimal.Decimal `db:"strike" json:"strike"`
+}
+
+var optionAssetClass = AssetClass{
+	Name:      strings.ToLower(string(finance.QuoteTypeOption)),
+	Title:     "Option",
+	QuoteType: finance.QuoteTypeOption,
+
+	Convert: NewAnonOptionFromAPI,
+	NewAPI:  func() interface{} { return &finance.Option{} },
+	Schema:  Option{},
 }
 
 func NewAnonOptionFromAPI(c interface{}) (o interface{}, ok bool) {
@@ -28,7 +41,7 @@ func NewAnonOptionFromAPI(c interface{}) (o interface{}, ok bool) {
 }
 
 func GetAnonOptionFromAPI(symbol string) (interface{}, error) {
-	return option.Get(symbol)
+	return DefaultProvider.GetAsset(context.Background(), finance.QuoteTypeOption, symbol)
 }
 
 func NewOptionFromAPI(e *finance.Option) (o Option, ok bool) {
@@ -44,8 +57,8 @@ func NewOptionFromAPI(e *finance.Option) (o Option, ok bool) {
 		UnderlyingExchangeSymbol: e.UnderlyingExchangeSymbol,
 
 		OpenInterest: e.OpenInterest,
-		ExpireDate:   e.ExpireDate,
-		Strike:       e.Strike,
+		ExpireDate:   NewTimestamp(e.ExpireDate, quoteLocation(&e.Quote)),
+		Strike:       NewPrice(e.Strike, e.CurrencyID),
 	}
 	return
 }
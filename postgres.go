@@ -0,0 +1,210 @@
+package odbc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// PostgresColumnType maps a Go field type onto the PostgreSQL column
+// type it is stored as.
+func PostgresColumnType(t reflect.Type) (string, error) {
+	switch t {
+	case decimalType:
+		return "NUMERIC", nil
+	case timeType, timestampType:
+		return "TIMESTAMPTZ", nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "TEXT", nil
+	case reflect.Bool:
+		return "BOOLEAN", nil
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+		return "BIGINT", nil
+	case reflect.Float64, reflect.Float32:
+		return "DOUBLE PRECISION", nil
+	}
+	return "", fmt.Errorf("postgres: no column type for %s", t)
+}
+
+// PostgresCreateTable returns the CREATE TABLE statement for storing
+// values shaped like v in table.
+func PostgresCreateTable(table string, v interface{}) (string, error) {
+	return createTableStatement(table, v, PostgresColumnType)
+}
+
+// PostgresStore writes quotes and ticks into PostgreSQL using
+// parameterized inserts.
+type PostgresStore struct {
+	db        *sqlx.DB
+	batchSize int
+}
+
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+var postgresDialect = dialect{
+	columnType: PostgresColumnType,
+	columns: func(ctx context.Context, db *sqlx.DB, table string) (map[string]string, error) {
+		rows := []struct {
+			Name string `db:"column_name"`
+			Type string `db:"data_type"`
+		}{}
+		err := db.SelectContext(ctx, &rows, `SELECT column_name, data_type FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1`, table)
+		if err != nil || len(rows) == 0 {
+			return nil, err
+		}
+
+		columns := map[string]string{}
+		for _, r := range rows {
+			columns[r.Name] = r.Type
+		}
+		return columns, nil
+	},
+	indexes: func(ctx context.Context, db *sqlx.DB, table string) (map[string]bool, error) {
+		names := []string{}
+		if err := db.SelectContext(ctx, &names, `SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = $1`, table); err != nil {
+			return nil, err
+		}
+
+		indexes := map[string]bool{}
+		for _, n := range names {
+			indexes[n] = true
+		}
+		return indexes, nil
+	},
+	convertUnix: func(ctx context.Context, db *sqlx.DB, table, column, columnType string) (string, error) {
+		if columnType != "bigint" && columnType != "integer" {
+			return "", nil
+		}
+		return fmt.Sprintf(
+			`ALTER TABLE %[1]s ALTER COLUMN %[2]s TYPE TIMESTAMPTZ USING CASE WHEN %[2]s = 0 THEN NULL ELSE to_timestamp(%[2]s) END`,
+			quoteIdentifier(table), quoteIdentifier(column),
+		), nil
+	},
+	floating: func(columnType string) bool {
+		return columnType == "double precision" || columnType == "real"
+	},
+	convertDecimals: func(ctx context.Context, db *sqlx.DB, table string, columns []string) ([]string, error) {
+		alters := make([]string, len(columns))
+		for i, c := range columns {
+			alters[i] = fmt.Sprintf("ALTER COLUMN %[1]s TYPE NUMERIC USING %[1]s::NUMERIC", quoteIdentifier(c))
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s %s", quoteIdentifier(table), strings.Join(alters, ", "))}, nil
+	},
+	dedupe: func(table string, columns []string) string {
+		equal := make([]string, len(columns))
+		for i, c := range columns {
+			equal[i] = fmt.Sprintf("a.%[1]s = b.%[1]s", quoteIdentifier(c))
+		}
+		return fmt.Sprintf(
+			`DELETE FROM %[1]s a USING %[1]s b WHERE a.ctid < b.ctid AND %[2]s`,
+			quoteIdentifier(table), strings.Join(equal, " AND "),
+		)
+	},
+}
+
+// CreateTables creates or migrates the tables of all registered asset
+// classes, the tick and the corporate action tables. It fails rather
+// than delete rows or rewrite stored values, see CheckAdditive.
+func (s *PostgresStore) CreateTables(ctx context.Context) error {
+	ms, err := s.PlanMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	if err := CheckAdditive(ms); err != nil {
+		return err
+	}
+	_, err = s.Migrate(ctx, ms)
+	return err
+}
+
+func (s *PostgresStore) SchemaVersion(ctx context.Context) (int, error) {
+	return schemaVersion(ctx, s.db, postgresDialect)
+}
+
+func (s *PostgresStore) PlanMigrations(ctx context.Context) ([]Migration, error) {
+	return planMigrations(ctx, s.db, postgresDialect, StoredTables())
+}
+
+func (s *PostgresStore) Migrate(ctx context.Context, ms []Migration) (int, error) {
+	return migrate(ctx, s.db, postgresDialect, ms)
+}
+
+// Insert stores v as a new row of table.
+func (s *PostgresStore) Insert(ctx context.Context, table string, v interface{}) error {
+	return insertRow(ctx, s.db, table, v)
+}
+
+func (s *PostgresStore) SetBatchSize(n int) {
+	s.batchSize = n
+}
+
+func (s *PostgresStore) WriteQuotes(ctx context.Context, table string, quotes []interface{}) error {
+	return writeQuotes(ctx, s.db, table, quotes, s.batchSize)
+}
+
+func (s *PostgresStore) WriteTicks(ctx context.Context, ticks []Tick, mode WriteMode) (WriteStats, error) {
+	return writeTicks(ctx, s.db, ticks, mode, s.batchSize)
+}
+
+func (s *PostgresStore) WriteValidRanges(ctx context.Context, rs []TickValidRange) error {
+	return writeValidRanges(ctx, s.db, rs, s.batchSize)
+}
+
+func (s *PostgresStore) WriteCorporateActions(ctx context.Context, as []CorporateAction) error {
+	return writeCorporateActions(ctx, s.db, as, s.batchSize)
+}
+
+func (s *PostgresStore) ReadCorporateActions(ctx context.Context, symbol string) ([]CorporateAction, error) {
+	return readCorporateActions(ctx, s.db, symbol)
+}
+
+func (s *PostgresStore) WatchlistNames(ctx context.Context) ([]string, error) {
+	return watchlistNames(ctx, s.db)
+}
+
+func (s *PostgresStore) ReadWatchlist(ctx context.Context, name string) ([]WatchlistMember, error) {
+	return readWatchlist(ctx, s.db, name)
+}
+
+func (s *PostgresStore) AddToWatchlist(ctx context.Context, ms []WatchlistMember) error {
+	return addToWatchlist(ctx, s.db, ms)
+}
+
+func (s *PostgresStore) RemoveFromWatchlist(ctx context.Context, name string, symbols []string) error {
+	return removeFromWatchlist(ctx, s.db, name, symbols)
+}
+
+func (s *PostgresStore) TickRange(ctx context.Context, symbol, granularity string) (first, last time.Time, ok bool, err error) {
+	return tickRange(ctx, s.db, symbol, granularity)
+}
+
+func (s *PostgresStore) FirstTradeDate(ctx context.Context, symbol string) (date time.Time, ok bool, err error) {
+	return firstTradeDate(ctx, s.db, symbol)
+}
+
+func (s *PostgresStore) ReadTicks(ctx context.Context, symbol, granularity string) ([]Tick, error) {
+	return readTicks(ctx, s.db, symbol, granularity)
+}
+
+func (s *PostgresStore) Flush(ctx context.Context) error {
+	return nil
+}
+
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,219 @@
+package odbc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+func TestPostgresCreateTable(t *testing.T) {
+	stmt, err := PostgresCreateTable(TickTableName, Tick{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`CREATE TABLE IF NOT EXISTS "ticks"`,
+		`"inserted_at" TIMESTAMPTZ`,
+		`"open" NUMERIC`,
+		`"volume" BIGINT`,
+		`"symbol" TEXT`,
+	} {
+		if !strings.Contains(stmt, want) {
+			t.Errorf("missing %q in\n%s", want, stmt)
+		}
+	}
+	if strings.Contains(stmt, "ranges") {
+		t.Errorf("ranges is not a stored column:\n%s", stmt)
+	}
+
+	for _, c := range AssetClasses() {
+		if _, err := PostgresCreateTable(c.TableName, c.Schema); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPostgresColumnType(t *testing.T) {
+	for v, want := range map[interface{}]string{
+		decimal.Decimal{}: "NUMERIC",
+		time.Time{}:       "TIMESTAMPTZ",
+		Timestamp{}:       "TIMESTAMPTZ",
+		"":                "TEXT",
+		false:             "BOOLEAN",
+		int(0):            "BIGINT",
+		int32(0):          "BIGINT",
+		int64(0):          "BIGINT",
+		float32(0):        "DOUBLE PRECISION",
+		float64(0):        "DOUBLE PRECISION",
+	} {
+		got, err := PostgresColumnType(reflect.TypeOf(v))
+		if err != nil || got != want {
+			t.Errorf("%T: got %q, %v, want %q", v, got, err, want)
+		}
+	}
+
+	if _, err := PostgresColumnType(reflect.TypeOf([]string{})); err == nil {
+		t.Error("[]string has no column type")
+	}
+}
+
+// TestPostgresStatements checks the statements PostgresStore sends
+// without a database: every stored column in the DDL and inserts bound
+// to numbered parameters.
+func TestPostgresStatements(t *testing.T) {
+	for table, schema := range StoredTables() {
+		stmt, err := PostgresCreateTable(table, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range ColumnsOf(schema) {
+			typ, _ := PostgresColumnType(c.Type)
+			if want := quoteIdentifier(c.Name) + " " + typ; !strings.Contains(stmt, want) {
+				t.Errorf("%s: missing %q in\n%s", table, want, stmt)
+			}
+		}
+
+		head, err := insertHead(table, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		columns := len(ColumnsOf(schema))
+		stmt = sqlx.Rebind(sqlx.DOLLAR, batchStatement(head, columns, 2, " ON CONFLICT DO NOTHING"))
+		last := fmt.Sprintf("$%d) ON CONFLICT DO NOTHING", 2*columns)
+		if !strings.HasPrefix(stmt, `INSERT INTO "`+table+`" ("`) || !strings.HasSuffix(stmt, last) || strings.Contains(stmt, "?") {
+			t.Errorf("%s: got batch insert\n%s", table, stmt)
+		}
+	}
+
+	tick := Tick{Symbol: "AAPL", Granularity: "1d"}
+	for _, named := range []string{mustInsertStatement(t, TickTableName, tick), tickUpdateStatement()} {
+		stmt, args, err := sqlx.Named(named, tick)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmt = sqlx.Rebind(sqlx.DOLLAR, stmt)
+		if !strings.Contains(stmt, fmt.Sprintf("$%d", len(args))) || strings.Contains(stmt, "?") {
+			t.Errorf("got %d args for\n%s", len(args), stmt)
+		}
+	}
+
+	ctx := context.Background()
+	if stmt, _ := postgresDialect.convertUnix(ctx, nil, TickTableName, "timestamp", "bigint"); !strings.Contains(stmt, `ALTER COLUMN "timestamp" TYPE TIMESTAMPTZ USING CASE WHEN "timestamp" = 0 THEN NULL ELSE to_timestamp("timestamp") END`) {
+		t.Errorf("got Unix conversion %q", stmt)
+	}
+	if stmt, _ := postgresDialect.convertUnix(ctx, nil, TickTableName, "timestamp", "timestamp with time zone"); stmt != "" {
+		t.Errorf("converts a timestamp column: %q", stmt)
+	}
+	stmts, _ := postgresDialect.convertDecimals(ctx, nil, TickTableName, []string{"open", "close"})
+	if want := `ALTER TABLE "ticks" ALTER COLUMN "open" TYPE NUMERIC USING "open"::NUMERIC, ALTER COLUMN "close" TYPE NUMERIC USING "close"::NUMERIC`; len(stmts) != 1 || stmts[0] != want {
+		t.Errorf("got decimal conversion %q, want %q", stmts, want)
+	}
+	if got, want := postgresDialect.dedupe(TickTableName, []string{"symbol", "timestamp"}), `DELETE FROM "ticks" a USING "ticks" b WHERE a.ctid < b.ctid AND a."symbol" = b."symbol" AND a."timestamp" = b."timestamp"`; got != want {
+		t.Errorf("got dedupe %q, want %q", got, want)
+	}
+}
+
+func mustInsertStatement(t *testing.T, table string, v interface{}) string {
+	stmt, err := insertStatement(table, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stmt
+}
+
+// postgresDSN returns FODBC_POSTGRES_DSN or, with initdb and pg_ctl on
+// the PATH, the DSN of a throwaway cluster that only listens on a
+// socket in a temporary directory and is stopped after the test.
+func postgresDSN(t *testing.T) string {
+	if dsn := os.Getenv("FODBC_POSTGRES_DSN"); dsn != "" {
+		return dsn
+	}
+
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		t.Skip("FODBC_POSTGRES_DSN not set and no initdb on the PATH")
+	}
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		t.Skip("FODBC_POSTGRES_DSN not set and no pg_ctl on the PATH")
+	}
+
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	if out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "--no-sync").CombinedOutput(); err != nil {
+		// initdb refuses to run as root, for one.
+		t.Skipf("initdb: %s\n%s", err, out)
+	}
+	opts := fmt.Sprintf("-c listen_addresses='' -k %s", dir)
+	if out, err := exec.Command(pgCtl, "-D", data, "-o", opts, "-l", filepath.Join(dir, "log"), "-w", "start").CombinedOutput(); err != nil {
+		t.Fatalf("pg_ctl start: %s\n%s", err, out)
+	}
+	t.Cleanup(func() {
+		exec.Command(pgCtl, "-D", data, "-m", "immediate", "-w", "stop").Run()
+	})
+	return fmt.Sprintf("host=%s user=postgres dbname=postgres sslmode=disable", dir)
+}
+
+// TestPostgresStore runs against the database named by
+// FODBC_POSTGRES_DSN or a local cluster, see postgresDSN.
+func TestPostgresStore(t *testing.T) {
+	dsn := postgresDSN(t)
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	s := NewPostgresStore(db)
+	if err := s.CreateTables(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tick := Tick{
+		DBEntry:     DBEntry{InsertedAt: time.Now().UTC()},
+		Open:        decimal.New(18915, -2),
+		Close:       decimal.New(18937, -2),
+		Symbol:      "TEST-" + time.Now().Format("150405.000000"),
+		Timestamp:   NewTimestamp(1701095400, time.UTC),
+		Granularity: "1d",
+	}
+	if err := s.Insert(ctx, TickTableName, tick); err != nil {
+		t.Fatal(err)
+	}
+
+	var close decimal.Decimal
+	if err := db.GetContext(ctx, &close, `SELECT "close" FROM "ticks" WHERE "symbol" = $1`, tick.Symbol); err != nil {
+		t.Fatal(err)
+	}
+	if !close.Equal(tick.Close) {
+		t.Fatalf("stored close %s, want %s", close, tick.Close)
+	}
+
+	s.SetBatchSize(2)
+	ticks := []Tick{tick}
+	for i := 1; i <= 4; i++ {
+		next := tick
+		next.Timestamp = NewTimestamp(1701095400+i*86400, time.UTC)
+		ticks = append(ticks, next)
+	}
+	stats, err := s.WriteTicks(ctx, append(ticks, ticks[1]), KeepStored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Inserted != 4 || stats.Unchanged != 2 {
+		t.Errorf("got %s, want 4 inserted and 2 unchanged", stats)
+	}
+}
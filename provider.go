@@ -0,0 +1,69 @@
+package odbc
+
+import (
+	"context"
+
+	"github.com/piquette/finance-go"
+	"github.com/piquette/finance-go/chart"
+)
+
+// Provider is a source of market data. Quotes and asset details are
+// returned as finance-go API types so that the NewXFromAPI converters
+// can be used regardless of where the data came from.
+type Provider interface {
+	GetQuote(ctx context.Context, symbol string) (*finance.Quote, error)
+	GetAsset(ctx context.Context, quoteType finance.QuoteType, symbol string) (interface{}, error)
+	GetChart(ctx context.Context, params *chart.Params) ChartIterator
+}
+
+// ChartIterator walks the bars of a chart response. *chart.Iter
+// satisfies it.
+type ChartIterator interface {
+	Next() bool
+	Bar() *finance.ChartBar
+	Meta() finance.ChartMeta
+	Err() error
+}
+
+var (
+	DefaultProvider Provider = YahooProvider{}
+)
+
+type chartSlice struct {
+	meta finance.ChartMeta
+	bars []finance.ChartBar
+	cur  *finance.ChartBar
+	err  error
+}
+
+// NewChartSlice returns a ChartIterator over bars already held in
+// memory. err is reported by Err once the bars are exhausted.
+func NewChartSlice(meta finance.ChartMeta, bars []finance.ChartBar, err error) ChartIterator {
+	return &chartSlice{
+		meta: meta,
+		bars: bars,
+		err:  err,
+	}
+}
+
+func (c *chartSlice) Next() bool {
+	if len(c.bars) == 0 {
+		return false
+	}
+
+	c.cur = &c.bars[0]
+	c.bars = c.bars[1:]
+	return true
+}
+
+func (c *chartSlice) Bar() *finance.ChartBar {
+	return c.cur
+}
+
+func (c *chartSlice) Meta() finance.ChartMeta {
+	return c.meta
+}
+
+func (c *chartSlice) Err() error {
+	return c.err
+}
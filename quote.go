@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/piquette/finance-go"
+	"github.com/shopspring/decimal"
 )
 
 // `db:"(.*)"`
@@ -20,44 +21,44 @@ type Quote struct {
 	Currency    string `db:"currency" json:"currency"`
 	IsTradeable bool   `db:"is_tradeable" json:"is_tradeable"`
 
-	Bid     float64 `db:"bid" json:"bid"`
-	BidSize int     `db:"bid_size" json:"bid_size"`
-	Ask     float64 `db:"ask" json:"ask"`
-	AskSize int     `db:"ask_size" json:"ask_size"`
-
-	PreMarketPrice         float64 `db:"pre_market_price" json:"pre_market_price"`
-	PreMarketChange        float64 `db:"pre_market_change" json:"pre_market_change"`
-	PreMarketChangePercent float64 `db:"pre_market_change_percent" json:"pre_market_change_percent"`
-	PreMarketTime          int     `db:"pre_market_time" json:"pre_market_time"`
-
-	RegularMarketChangePercent float64 `db:"regular_market_change_percent" json:"regular_market_change_percent"`
-	RegularMarketPreviousClose float64 `db:"regular_market_previous_close" json:"regular_market_previous_close"`
-	RegularMarketPrice         float64 `db:"regular_market_price" json:"regular_market_price"`
-	RegularMarketTime          int     `db:"regular_market_time" json:"regular_market_time"`
-	RegularMarketChange        float64 `db:"regular_market_change" json:"regular_market_change"`
-	RegularMarketDayHigh       float64 `db:"regular_market_day_high" json:"regular_market_day_high"`
-	RegularMarketDayLow        float64 `db:"regular_market_day_low" json:"regular_market_day_low"`
-	RegularMarketVolume        int     `db:"regular_market_volume" json:"regular_market_volume"`
-
-	PostMarketPrice         float64 `db:"post_market_price" json:"post_market_price"`
-	PostMarketChange        float64 `db:"post_market_change" json:"post_market_change"`
-	PostMarketChangePercent float64 `db:"post_market_change_percent" json:"post_market_change_percent"`
-	PostMarketTime          int     `db:"post_market_time" json:"post_market_time"`
-
-	FiftyTwoWeekLowChange         float64 `db:"fifty_two_week_low_change" json:"fifty_two_week_low_change"`
-	FiftyTwoWeekLowChangePercent  float64 `db:"fifty_two_week_low_change_percent" json:"fifty_two_week_low_change_percent"`
-	FiftyTwoWeekHighChange        float64 `db:"fifty_two_week_high_change" json:"fifty_two_week_high_change"`
-	FiftyTwoWeekHighChangePercent float64 `db:"fifty_two_week_high_change_percent" json:"fifty_two_week_high_change_percent"`
-	FiftyTwoWeekLow               float64 `db:"fifty_two_week_low" json:"fifty_two_week_low"`
-	FiftyTwoWeekHigh              float64 `db:"fifty_two_week_high" json:"fifty_two_week_high"`
-
-	FiftyDayAverage              float64 `db:"fifty_day_average" json:"fifty_day_average"`
-	FiftyDayAverageChange        float64 `db:"fifty_day_average_change" json:"fifty_day_average_change"`
-	FiftyDayAverageChangePercent float64 `db:"fifty_day_average_change_percent" json:"fifty_day_average_change_percent"`
-
-	TwoHundredDayAverage              float64 `db:"two_hundred_day_average" json:"two_hundred_day_average"`
-	TwoHundredDayAverageChange        float64 `db:"two_hundred_day_average_change" json:"two_hundred_day_average_change"`
-	TwoHundredDayAverageChangePercent float64 `db:"two_hundred_day_average_change_percent" json:"two_hundred_day_average_change_percent"`
+	Bid     decimal.Decimal `db:"bid" json:"bid"`
+	BidSize int             `db:"bid_size" json:"bid_size"`
+	Ask     decimal.Decimal `db:"ask" json:"ask"`
+	AskSize int             `db:"ask_size" json:"ask_size"`
+
+	PreMarketPrice         decimal.Decimal `db:"pre_market_price" json:"pre_market_price"`
+	PreMarketChange        decimal.Decimal `db:"pre_market_change" json:"pre_market_change"`
+	PreMarketChangePercent decimal.Decimal `db:"pre_market_change_percent" json:"pre_market_change_percent"`
+	PreMarketTime          Timestamp       `db:"pre_market_time" json:"pre_market_time"`
+
+	RegularMarketChangePercent decimal.Decimal `db:"regular_market_change_percent" json:"regular_market_change_percent"`
+	RegularMarketPreviousClose decimal.Decimal `db:"regular_market_previous_close" json:"regular_market_previous_close"`
+	RegularMarketPrice         decimal.Decimal `db:"regular_market_price" json:"regular_market_price"`
+	RegularMarketTime          Timestamp       `db:"regular_market_time" json:"regular_market_time"`
+	RegularMarketChange        decimal.Decimal `db:"regular_market_change" json:"regular_market_change"`
+	RegularMarketDayHigh       decimal.Decimal `db:"regular_market_day_high" json:"regular_market_day_high"`
+	RegularMarketDayLow        decimal.Decimal `db:"regular_market_day_low" json:"regular_market_day_low"`
+	RegularMarketVolume        int             `db:"regular_market_volume" json:"regular_market_volume"`
+
+	PostMarketPrice         decimal.Decimal `db:"post_market_price" json:"post_market_price"`
+	PostMarketChange        decimal.Decimal `db:"post_market_change" json:"post_market_change"`
+	PostMarketChangePercent decimal.Decimal `db:"post_market_change_percent" json:"post_market_change_percent"`
+	PostMarketTime          Timestamp       `db:"post_market_time" json:"post_market_time"`
+
+	FiftyTwoWeekLowChange         decimal.Decimal `db:"fifty_two_week_low_change" json:"fifty_two_week_low_change"`
+	FiftyTwoWeekLowChangePercent  decimal.Decimal `db:"fifty_two_week_low_change_percent" json:"fifty_two_week_low_change_percent"`
+	FiftyTwoWeekHighChange        decimal.Decimal `db:"fifty_two_week_high_change" json:"fifty_two_week_high_change"`
+	FiftyTwoWeekHighChangePercent decimal.Decimal `db:"fifty_two_week_high_change_percent" json:"fifty_two_week_high_change_percent"`
+	FiftyTwoWeekLow               decimal.Decimal `db:"fifty_two_week_low" json:"fifty_two_week_low"`
+	FiftyTwoWeekHigh              decimal.Decimal `db:"fifty_two_week_high" json:"fifty_two_week_high"`
+
+	FiftyDayAverage              decimal.Decimal `db:"fifty_day_average" json:"fifty_day_average"`
+	FiftyDayAverageChange        decimal.Decimal `db:"fifty_day_average_change" json:"fifty_day_average_change"`
+	FiftyDayAverageChangePercent decimal.Decimal `db:"fifty_day_average_change_percent" json:"fifty_day_average_change_percent"`
+
+	TwoHundredDayAverage              decimal.Decimal `db:"two_hundred_day_average" json:"two_hundred_day_average"`
+	TwoHundredDayAverageChange        decimal.Decimal `db:"two_hundred_day_average_change" json:"two_hundred_day_average_change"`
+	TwoHundredDayAverageChangePercent decimal.Decimal `db:"two_hundred_day_average_change_percent" json:"two_hundred_day_average_change_percent"`
 
 	AverageDailyVolumeThreeMonth int `db:"average_daily_volume_three_month" json:"average_daily_volume_three_month"`
 	AverageDailyVolumeTenDay     int `db:"average_daily_volume_ten_day" json:"average_daily_volume_ten_day"`
@@ -78,7 +79,23 @@ func (q Quote) GetType() string {
 	return q.Type
 }
 
+// quoteLocation returns the exchange timezone of d.
+func quoteLocation(d *finance.Quote) *time.Location {
+	return exchangeLocation(d.ExchangeTimezoneName, d.GMTOffSetMilliseconds/1000)
+}
+
+// MarketOpen reports whether the exchange of d is trading, in the regular
+// session or the pre- or post-market, according to its market state.
+func MarketOpen(d *finance.Quote) bool {
+	switch d.MarketState {
+	case finance.MarketStateRegular, finance.MarketStatePre, finance.MarketStatePost:
+		return true
+	}
+	return false
+}
+
 func NewQuoteFromAPI(d *finance.Quote) Quote {
+	loc := quoteLocation(d)
 	return Quote{
 		DBEntry: DBEntry{
 			InsertedAt: time.Now().UTC(),
@@ -93,44 +110,44 @@ func NewQuoteFromAPI(d *finance.Quote) Quote {
 		Currency:    d.CurrencyID,
 		IsTradeable: d.IsTradeable,
 
-		Bid:     d.Bid,
+		Bid:     NewPrice(d.Bid, d.CurrencyID),
 		BidSize: d.BidSize,
-		Ask:     d.Ask,
+		Ask:     NewPrice(d.Ask, d.CurrencyID),
 		AskSize: d.AskSize,
 
-		PreMarketPrice:         d.PreMarketPrice,
-		PreMarketChange:        d.PreMarketChange,
-		PreMarketChangePercent: d.PreMarketChangePercent,
-		PreMarketTime:          d.PreMarketTime,
-
-		RegularMarketChangePercent: d.RegularMarketChangePercent,
-		RegularMarketPreviousClose: d.RegularMarketPreviousClose,
-		RegularMarketPrice:         d.RegularMarketPrice,
-		RegularMarketTime:          d.RegularMarketTime,
-		RegularMarketChange:        d.RegularMarketChange,
-		RegularMarketDayHigh:       d.RegularMarketDayHigh,
-		RegularMarketDayLow:        d.RegularMarketDayLow,
+		PreMarketPrice:         NewPrice(d.PreMarketPrice, d.CurrencyID),
+		PreMarketChange:        NewPrice(d.PreMarketChange, d.CurrencyID),
+		PreMarketChangePercent: NewRatio(d.PreMarketChangePercent),
+		PreMarketTime:          NewTimestamp(d.PreMarketTime, loc),
+
+		RegularMarketChangePercent: NewRatio(d.RegularMarketChangePercent),
+		RegularMarketPreviousClose: NewPrice(d.RegularMarketPreviousClose, d.CurrencyID),
+		RegularMarketPrice:         NewPrice(d.RegularMarketPrice, d.CurrencyID),
+		RegularMarketTime:          NewTimestamp(d.RegularMarketTime, loc),
+		RegularMarketChange:        NewPrice(d.RegularMarketChange, d.CurrencyID),
+		RegularMarketDayHigh:       NewPrice(d.RegularMarketDayHigh, d.CurrencyID),
+		RegularMarketDayLow:        NewPrice(d.RegularMarketDayLow, d.CurrencyID),
 		RegularMarketVolume:        d.RegularMarketVolume,
 
-		PostMarketPrice:         d.PostMarketPrice,
-		PostMarketChange:        d.PostMarketChange,
-		PostMarketChangePercent: d.PostMarketChangePercent,
-		PostMarketTime:          d.PostMarketTime,
-
-		FiftyTwoWeekLowChange:         d.FiftyTwoWeekLowChange,
-		FiftyTwoWeekLowChangePercent:  d.FiftyTwoWeekLowChangePercent,
-		FiftyTwoWeekHighChange:        d.FiftyTwoWeekHighChange,
-		FiftyTwoWeekHighChangePercent: d.FiftyTwoWeekHighChangePercent,
-		FiftyTwoWeekLow:               d.FiftyTwoWeekLow,
-		FiftyTwoWeekHigh:              d.FiftyTwoWeekHigh,
-
-		FiftyDayAverage:              d.FiftyDayAverage,
-		FiftyDayAverageChange:        d.FiftyDayAverageChange,
-		FiftyDayAverageChangePercent: d.FiftyDayAverageChangePercent,
-
-		TwoHundredDayAverage:              d.TwoHundredDayAverage,
-		TwoHundredDayAverageChange:        d.TwoHundredDayAverageChange,
-		TwoHundredDayAverageChangePercent: d.TwoHundredDayAverageChangePercent,
+		PostMarketPrice:         NewPrice(d.PostMarketPrice, d.CurrencyID),
+		PostMarketChange:        NewPrice(d.PostMarketChange, d.CurrencyID),
+		PostMarketChangePercent: NewRatio(d.PostMarketChangePercent),
+		PostMarketTime:          NewTimestamp(d.PostMarketTime, loc),
+
+		FiftyTwoWeekLowChange:         NewPrice(d.FiftyTwoWeekLowChange, d.CurrencyID),
+		FiftyTwoWeekLowChangePercent:  NewRatio(d.FiftyTwoWeekLowChangePercent),
+		FiftyTwoWeekHighChange:        NewPrice(d.FiftyTwoWeekHighChange, d.CurrencyID),
+		FiftyTwoWeekHighChangePercent: NewRatio(d.FiftyTwoWeekHighChangePercent),
+		FiftyTwoWeekLow:               NewPrice(d.FiftyTwoWeekLow, d.CurrencyID),
+		FiftyTwoWeekHigh:              NewPrice(d.FiftyTwoWeekHigh, d.CurrencyID),
+
+		FiftyDayAverage:              NewPrice(d.FiftyDayAverage, d.CurrencyID),
+		FiftyDayAverageChange:        NewPrice(d.FiftyDayAverageChange, d.CurrencyID),
+		FiftyDayAverageChangePercent: NewRatio(d.FiftyDayAverageChangePercent),
+
+		TwoHundredDayAverage:              NewPrice(d.TwoHundredDayAverage, d.CurrencyID),
+		TwoHundredDayAverageChange:        NewPrice(d.TwoHundredDayAverageChange, d.CurrencyID),
+		TwoHundredDayAverageChangePercent: NewRatio(d.TwoHundredDayAverageChangePercent),
 
 		AverageDailyVolumeThreeMonth: d.AverageDailyVolume3Month,
 		AverageDailyVolumeTenDay:     d.AverageDailyVolume10Day,
@@ -0,0 +1,116 @@
+package odbc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/piquette/finance-go"
+	"github.com/piquette/finance-go/chart"
+)
+
+// TokenBucket limits events to rate per second on average, allowing
+// bursts of up to burst events. A rate of 0 or less means no limit. It
+// is safe for concurrent use.
+type TokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until the next event may happen or ctx is done.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if b.rate <= 0 {
+		return ctx.Err()
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// RateLimitedProvider makes every call to the wrapped Provider wait for
+// the shared TokenBucket, so concurrent downloads stay below the rate
+// limits of the API.
+type RateLimitedProvider struct {
+	Provider
+
+	limiter *TokenBucket
+}
+
+func NewRateLimitedProvider(p Provider, limiter *TokenBucket) *RateLimitedProvider {
+	return &RateLimitedProvider{
+		Provider: p,
+		limiter:  limiter,
+	}
+}
+
+func (r *RateLimitedProvider) GetQuote(ctx context.Context, symbol string) (*finance.Quote, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.Provider.GetQuote(ctx, symbol)
+}
+
+func (r *RateLimitedProvider) GetAsset(ctx context.Context, quoteType finance.QuoteType, symbol string) (interface{}, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.Provider.GetAsset(ctx, quoteType, symbol)
+}
+
+func (r *RateLimitedProvider) GetChart(ctx context.Context, params *chart.Params) ChartIterator {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return NewChartSlice(finance.ChartMeta{}, nil, err)
+	}
+	return r.Provider.GetChart(ctx, params)
+}
+
+func (r *RateLimitedProvider) GetCorporateActions(ctx context.Context, symbol string, start, end time.Time) ([]CorporateAction, error) {
+	p, ok := r.Provider.(CorporateActionProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider cannot list corporate actions")
+	}
+	if err := r.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.GetCorporateActions(ctx, symbol, start, end)
+}
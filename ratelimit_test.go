@@ -0,0 +1,33 @@
+package odbc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	b := NewTokenBucket(50, 2)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The burst of 2 passes right away, the other 2 wait 20ms each.
+	if d := time.Since(start); d < 35*time.Millisecond || d > 500*time.Millisecond {
+		t.Errorf("4 events took %s, want about 40ms", d)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := b.Wait(ctx); err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+
+	if err := NewTokenBucket(0, 1).Wait(context.Background()); err != nil {
+		t.Errorf("unlimited bucket: %s", err)
+	}
+}
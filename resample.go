@@ -0,0 +1,150 @@
+package odbc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// barPeriod describes the length of a bar: either a fixed intraday
+// duration, a number of days (1 for daily, 5 for Yahoo's weekly bars)
+// or a number of calendar months.
+type barPeriod struct {
+	intraday time.Duration
+	days     int
+	months   int
+}
+
+func parseBarPeriod(granularity string) (p barPeriod, err error) {
+	for _, u := range []struct {
+		suffix string
+		set    func(n int)
+	}{
+		{"mo", func(n int) { p.months = n }},
+		{"wk", func(n int) { p.days = 5 * n }},
+		{"d", func(n int) { p.days = n }},
+		{"h", func(n int) { p.intraday = time.Duration(n) * time.Hour }},
+		{"m", func(n int) { p.intraday = time.Duration(n) * time.Minute }},
+	} {
+		if !strings.HasSuffix(granularity, u.suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(granularity, u.suffix))
+		if err != nil || n <= 0 {
+			break
+		}
+		u.set(n)
+		if p.days != 0 && p.days != 1 && p.days != 5 {
+			break
+		}
+		return p, nil
+	}
+	return p, fmt.Errorf("unsupported granularity %q", granularity)
+}
+
+// covers reports whether every bar of o lies within exactly one bar of p.
+func (p barPeriod) covers(o barPeriod) bool {
+	switch {
+	case p.intraday != 0:
+		return o.intraday != 0 && p.intraday > o.intraday && p.intraday%o.intraday == 0
+	case p.days != 0:
+		return o.intraday != 0 || o.days < p.days
+	default:
+		return o.intraday != 0 || o.days == 1 || (o.months != 0 && o.months < p.months && p.months%o.months == 0)
+	}
+}
+
+// bucket returns the start of the bar of period p that t falls into.
+// Intraday bars are aligned to the session open and never span the
+// open or the close, so pre- and post-market bars are kept apart from
+// regular ones. Longer bars start at the session open of their first
+// day.
+func bucket(h TradingHours, p barPeriod, t time.Time) time.Time {
+	t = t.In(h.Location)
+	if p.intraday != 0 {
+		open, close := h.at(t, h.RegularStart), h.at(t, h.RegularEnd)
+		anchor := open
+		if !t.Before(close) {
+			anchor = close
+		}
+
+		n := t.Sub(anchor) / p.intraday
+		if t.Before(anchor) && t.Sub(anchor)%p.intraday != 0 {
+			n--
+		}
+		return anchor.Add(n * p.intraday)
+	}
+
+	day := t
+	switch {
+	case p.days == 5:
+		day = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case p.months != 0:
+		m := (int(day.Month()) - 1) / p.months * p.months
+		day = time.Date(day.Year(), time.Month(m+1), 1, 0, 0, 0, 0, h.Location)
+	}
+	return h.at(day, h.RegularStart)
+}
+
+// Resample aggregates the bars of one symbol and granularity into bars
+// of a coarser granularity: open is the first open, high the highest
+// high, low the lowest low, close the last close and volume the sum of
+// all volumes. Buckets follow the regular session and timezone of the
+// exchange, see bucket.
+func Resample(ticks []Tick, granularity string) ([]Tick, error) {
+	if len(ticks) == 0 {
+		return []Tick{}, nil
+	}
+
+	to, err := parseBarPeriod(granularity)
+	if err != nil {
+		return nil, err
+	}
+	from, err := parseBarPeriod(ticks[0].Granularity)
+	if err != nil {
+		return nil, err
+	}
+	if !to.covers(from) {
+		return nil, fmt.Errorf("cannot resample %s bars into %s bars", ticks[0].Granularity, granularity)
+	}
+
+	sorted := make([]Tick, len(ticks))
+	copy(sorted, ticks)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp.Time) })
+
+	h := TradingHoursOf(&sorted[0])
+	bars := []Tick{}
+	for i := range sorted {
+		t := &sorted[i]
+		if t.Symbol != sorted[0].Symbol || t.Granularity != sorted[0].Granularity {
+			return nil, fmt.Errorf("cannot resample %s %s and %s %s bars together", sorted[0].Symbol, sorted[0].Granularity, t.Symbol, t.Granularity)
+		}
+
+		start := Timestamp{bucket(h, to, t.Timestamp.Time)}
+		if n := len(bars); n > 0 && bars[n-1].Timestamp.Equal(start.Time) {
+			b := &bars[n-1]
+			if t.High.GreaterThan(b.High) {
+				b.High = t.High
+			}
+			if t.Low.LessThan(b.Low) {
+				b.Low = t.Low
+			}
+			b.Close = t.Close
+			b.AdjClose = t.AdjClose
+			b.Volume += t.Volume
+			continue
+		}
+
+		b := *t
+		b.InsertedAt = time.Now().UTC()
+		b.Timestamp = start
+		b.Granularity = granularity
+		b.ResampledFrom = t.Granularity
+		b.Ranges = nil
+		ClassifySession(&b)
+		bars = append(bars, b)
+	}
+	return bars, nil
+}
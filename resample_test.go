@@ -0,0 +1,75 @@
+package odbc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestResample(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	open := time.Date(2023, 3, 14, 9, 30, 0, 0, ny)
+
+	bar := func(at time.Time, o, h, l, c int64, v int) Tick {
+		return Tick{
+			Symbol:           "AAPL",
+			Granularity:      "30m",
+			Timestamp:        Timestamp{at},
+			Open:             decimal.New(o, 0),
+			High:             decimal.New(h, 0),
+			Low:              decimal.New(l, 0),
+			Close:            decimal.New(c, 0),
+			Volume:           v,
+			ExchangeTimezone: "America/New_York",
+			RegularStart:     Timestamp{open},
+			RegularEnd:       Timestamp{open.Add(390 * time.Minute)},
+		}
+	}
+
+	ticks := []Tick{
+		bar(open.Add(-30*time.Minute), 9, 9, 9, 9, 5),
+		bar(open.Add(30*time.Minute), 12, 14, 11, 13, 20),
+		bar(open, 10, 12, 9, 11, 10),
+		bar(open.Add(360*time.Minute), 20, 21, 19, 20, 1),
+		bar(open.AddDate(0, 0, 1), 30, 31, 29, 30, 1),
+	}
+
+	hourly, err := Resample(ticks, "1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hourly) != 4 {
+		t.Fatalf("got %d hourly bars, want 4", len(hourly))
+	}
+	if h := hourly[0]; !h.Timestamp.Equal(open.Add(-60*time.Minute)) || h.Volume != 5 {
+		t.Errorf("pre-market bar %+v was merged into the session", h)
+	}
+	h := hourly[1]
+	if !h.Timestamp.Equal(open) || h.Granularity != "1h" ||
+		!h.Open.Equal(decimal.New(10, 0)) || !h.High.Equal(decimal.New(14, 0)) ||
+		!h.Low.Equal(decimal.New(9, 0)) || !h.Close.Equal(decimal.New(13, 0)) || h.Volume != 30 {
+		t.Errorf("unexpected first session bar %+v", h)
+	}
+	if h := hourly[2]; !h.Timestamp.Equal(open.Add(360 * time.Minute)) {
+		t.Errorf("last bar of the session starts at %v", h.Timestamp.In(ny))
+	}
+
+	daily, err := Resample(ticks, "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(daily) != 2 || !daily[0].Timestamp.Equal(open) || daily[0].Volume != 36 {
+		t.Errorf("unexpected daily bars %+v", daily)
+	}
+
+	if _, err := Resample(hourly, "30m"); err == nil {
+		t.Error("expected an error when resampling into a finer granularity")
+	}
+	if _, err := Resample(ticks, "45m"); err == nil {
+		t.Error("expected an error for a granularity that does not divide evenly")
+	}
+}
@@ -0,0 +1,136 @@
+package odbc
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RetryPolicy retries transient errors with exponential backoff and full
+// jitter: the n-th retry waits a random duration of up to
+// min(Max, Base*2^(n-1)).
+type RetryPolicy struct {
+	Attempts int
+	Base     time.Duration
+	Max      time.Duration
+}
+
+var (
+	DefaultRetryPolicy = RetryPolicy{Attempts: 4, Base: 500 * time.Millisecond, Max: 30 * time.Second}
+)
+
+// Do calls fn until it succeeds, fails with an error that is not
+// transient, ctx is done or all attempts are used up. It returns the
+// number of calls made and the last error.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) (attempts int, err error) {
+	for {
+		attempts++
+		if err = fn(); err == nil || attempts >= p.Attempts || !IsTransient(err) {
+			return
+		}
+
+		backoff := p.Base << uint(attempts-1)
+		if backoff <= 0 || backoff > p.Max {
+			backoff = p.Max
+		}
+		t := time.NewTimer(time.Duration(rand.Int63n(int64(backoff) + 1)))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+	}
+}
+
+// HTTPStatusError is returned for Yahoo responses whose status is worth
+// retrying, since finance-go reports every error status alike.
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("upstream api answered %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+type retryableStatusTransport struct {
+	http.RoundTripper
+}
+
+func (t retryableStatusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.RoundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+		res.Body.Close()
+		return nil, &HTTPStatusError{res.StatusCode}
+	}
+	return res, nil
+}
+
+// NewHTTPClient returns an HTTP client for finance-go that fails
+// responses with status 429 or 5xx with an HTTPStatusError, so they can
+// be told apart from unknown symbols. Install it with
+// finance.SetHTTPClient before the first request.
+func NewHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout:   80 * time.Second,
+		Transport: retryableStatusTransport{http.DefaultTransport},
+	}
+}
+
+// Messages of transient errors that finance-go and some database
+// drivers only hand on as text.
+var transientMessages = []string{
+	"upstream api answered",
+	"connection reset",
+	"connection refused",
+	"broken pipe",
+	"unexpected eof",
+	"timeout",
+	"database is locked",
+	"database table is locked",
+}
+
+// IsTransient reports whether err is worth retrying: network failures,
+// rate limiting and server errors of the API, busy or lost database
+// connections and serialization failures.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	var statusErr *HTTPStatusError
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &statusErr), errors.As(err, &netErr):
+		return true
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	case errors.As(err, &pqErr):
+		switch pqErr.Code {
+		case "40001", "40P01", "57P01", "57P02", "57P03":
+			return true
+		}
+		return pqErr.Code.Class() == "08" || pqErr.Code.Class() == "53"
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, m := range transientMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
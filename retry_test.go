@@ -0,0 +1,53 @@
+package odbc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestIsTransient(t *testing.T) {
+	for err, want := range map[error]bool{
+		errors.New("code: remote-error, detail: Get \"https://query1.finance.yahoo.com\": upstream api answered 429 Too Many Requests"): true,
+		fmt.Errorf("write: %w", &HTTPStatusError{503}): true,
+		errors.New("database is locked"):               true,
+		&pq.Error{Code: "40001"}:                       true,
+		&pq.Error{Code: "23505"}:                       false,
+		errors.New("code: remote-error, detail: error response recieved from upstream api"): false,
+		context.Canceled: false,
+	} {
+		if got := IsTransient(err); got != want {
+			t.Errorf("IsTransient(%q) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	p := RetryPolicy{Attempts: 3, Base: time.Millisecond, Max: 5 * time.Millisecond}
+
+	calls := 0
+	attempts, err := p.Do(context.Background(), func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("connection reset by peer")
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Errorf("got %d attempts and %v, want 2 and no error", attempts, err)
+	}
+
+	attempts, err = p.Do(context.Background(), func() error { return errors.New("timeout") })
+	if err == nil || attempts != 3 {
+		t.Errorf("got %d attempts and %v, want 3 and an error", attempts, err)
+	}
+
+	attempts, _ = p.Do(context.Background(), func() error { return errors.New("unknown symbol") })
+	if attempts != 1 {
+		t.Errorf("retried a permanent error %d times", attempts-1)
+	}
+}
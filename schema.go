@@ -0,0 +1,57 @@
+package odbc
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Column is a single stored field as declared by a `db:"..."` tag.
+type Column struct {
+	Name string
+	Type reflect.Type
+}
+
+// ColumnsOf lists the columns of the struct v in declaration order.
+// Embedded structs such as DBEntry and Quote are flattened and fields
+// tagged `db:"-"` or without a db tag are skipped.
+func ColumnsOf(v interface{}) []Column {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return appendColumns(nil, t)
+}
+
+func appendColumns(cs []Column, t reflect.Type) []Column {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			cs = appendColumns(cs, f.Type)
+			continue
+		}
+		if tag == "" {
+			continue
+		}
+
+		cs = append(cs, Column{
+			Name: strings.Split(tag, ",")[0],
+			Type: f.Type,
+		})
+	}
+	return cs
+}
+
+// ColumnNames returns the names of ColumnsOf(v).
+func ColumnNames(v interface{}) []string {
+	cs := ColumnsOf(v)
+	names := make([]string, len(cs))
+	for i, c := range cs {
+		names[i] = c.Name
+	}
+	return names
+}
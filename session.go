@@ -0,0 +1,131 @@
+package odbc
+
+import (
+	"time"
+
+	"github.com/jakoblorz/finance-odbc/calendar"
+	yfin "github.com/piquette/finance-go"
+)
+
+// Trading sessions a bar can belong to.
+const (
+	SessionPre     = calendar.Pre
+	SessionRegular = calendar.Regular
+	SessionPost    = calendar.Post
+	SessionClosed  = calendar.Closed
+)
+
+// CalendarOf returns the calendar of the exchange t was traded on. Only
+// securities fall back to the calendar of their exchange's timezone;
+// currencies and futures trade well beyond the hours of the stock
+// exchange next door, so they need a calendar of their own exchange.
+func CalendarOf(t *Tick) (*calendar.Calendar, bool) {
+	switch yfin.QuoteType(t.Type) {
+	case yfin.QuoteTypeCryptoPair:
+		return calendar.Crypto, true
+	case yfin.QuoteTypeEquity, yfin.QuoteTypeETF, yfin.QuoteTypeIndex, yfin.QuoteTypeMutualFund, yfin.QuoteTypeOption:
+		return calendar.Lookup(t.ExchangeName, t.ExchangeTimezone)
+	}
+	return calendar.Lookup(t.ExchangeName, "")
+}
+
+// TradingHours are the daily trading periods of an exchange as wall
+// clock times of day in Location, so they stay correct across daylight
+// saving changes. Exchanges trade Monday to Friday unless AllWeek is
+// set.
+type TradingHours struct {
+	Location *time.Location
+	AllWeek  bool
+
+	PreStart     time.Duration
+	RegularStart time.Duration
+	RegularEnd   time.Duration
+	PostEnd      time.Duration
+}
+
+func timeOfDay(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+// TradingHoursOf derives the trading hours from the current trading
+// period Yahoo reported along with t. Without one, the whole day is
+// taken as the regular session.
+func TradingHoursOf(t *Tick) TradingHours {
+	loc := t.Location()
+
+	h := TradingHours{Location: loc, RegularEnd: 24 * time.Hour, PostEnd: 24 * time.Hour}
+	if !t.RegularEnd.After(t.RegularStart.Time) {
+		h.AllWeek = true
+		return h
+	}
+
+	h.RegularStart = timeOfDay(t.RegularStart.Time.In(loc))
+	h.RegularEnd = h.RegularStart + t.RegularEnd.Sub(t.RegularStart.Time)
+	h.PreStart, h.PostEnd = h.RegularStart, h.RegularEnd
+	if t.PreEnd.After(t.PreStart.Time) {
+		h.PreStart = h.RegularStart - t.PreEnd.Sub(t.PreStart.Time)
+	}
+	if t.PostEnd.After(t.PostStart.Time) {
+		h.PostEnd = h.RegularEnd + t.PostEnd.Sub(t.PostStart.Time)
+	}
+	h.AllWeek = h.RegularEnd-h.RegularStart >= 24*time.Hour
+	return h
+}
+
+// at returns the wall clock time of day d on the day of t.
+func (h TradingHours) at(t time.Time, d time.Duration) time.Time {
+	t = t.In(h.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, int(d/time.Second), 0, h.Location)
+}
+
+// Session classifies at into the pre-market, regular, post-market or
+// closed session.
+func (h TradingHours) Session(at time.Time) string {
+	at = at.In(h.Location)
+	if !h.AllWeek && (at.Weekday() == time.Saturday || at.Weekday() == time.Sunday) {
+		return SessionClosed
+	}
+
+	switch d := timeOfDay(at); {
+	case d >= h.RegularStart && d < h.RegularEnd:
+		return SessionRegular
+	case d >= h.PreStart && d < h.RegularStart:
+		return SessionPre
+	case d >= h.RegularEnd && d < h.PostEnd:
+		return SessionPost
+	}
+	return SessionClosed
+}
+
+// ClassifySession sets t.Session from the calendar of its exchange, or
+// from the trading hours of t if the exchange is unknown. Bars of a day
+// or longer cover whole sessions and count as regular.
+func ClassifySession(t *Tick) {
+	if p, err := parseBarPeriod(t.Granularity); err == nil && p.intraday == 0 {
+		t.Session = SessionRegular
+		return
+	}
+
+	at := t.Timestamp.Time
+	if c, ok := CalendarOf(t); ok {
+		t.Session = c.Phase(at)
+		return
+	}
+	t.Session = TradingHoursOf(t).Session(at)
+}
+
+// FilterSessions returns the ticks whose session is one of sessions.
+func FilterSessions(ticks []Tick, sessions ...string) []Tick {
+	keep := map[string]bool{}
+	for _, s := range sessions {
+		keep[s] = true
+	}
+
+	filtered := []Tick{}
+	for _, t := range ticks {
+		if keep[t.Session] {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,81 @@
+package odbc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTradingHoursSession(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// The current trading period was reported in winter, the bars are
+	// from summer: sessions follow the exchange's wall clock.
+	day := func(h, m int) time.Time { return time.Date(2023, 1, 10, h, m, 0, 0, ny) }
+	tick := Tick{
+		ExchangeTimezone: "America/New_York",
+		PreStart:         Timestamp{day(4, 0)},
+		PreEnd:           Timestamp{day(9, 30)},
+		RegularStart:     Timestamp{day(9, 30)},
+		RegularEnd:       Timestamp{day(16, 0)},
+		PostStart:        Timestamp{day(16, 0)},
+		PostEnd:          Timestamp{day(20, 0)},
+	}
+	h := TradingHoursOf(&tick)
+
+	for at, want := range map[time.Time]string{
+		time.Date(2023, 7, 11, 3, 0, 0, 0, ny):   SessionClosed,
+		time.Date(2023, 7, 11, 9, 0, 0, 0, ny):   SessionPre,
+		time.Date(2023, 7, 11, 9, 30, 0, 0, ny):  SessionRegular,
+		time.Date(2023, 7, 11, 15, 59, 0, 0, ny): SessionRegular,
+		time.Date(2023, 7, 11, 16, 0, 0, 0, ny):  SessionPost,
+		time.Date(2023, 7, 15, 12, 0, 0, 0, ny):  SessionClosed,
+	} {
+		if got := h.Session(at); got != want {
+			t.Errorf("Session(%v) = %s, want %s", at, got, want)
+		}
+	}
+
+	ticks := []Tick{{Session: SessionPre}, {Session: SessionRegular}, {Session: SessionPost}}
+	if got := FilterSessions(ticks, SessionRegular); len(got) != 1 || got[0].Session != SessionRegular {
+		t.Errorf("FilterSessions kept %+v", got)
+	}
+}
+
+func TestCalendarOf(t *testing.T) {
+	for _, c := range []struct {
+		tick Tick
+		want string
+	}{
+		{Tick{Type: "EQUITY", ExchangeName: "NMS", ExchangeTimezone: "America/New_York"}, "NYSE"},
+		{Tick{Type: "EQUITY", ExchangeName: "LSE", ExchangeTimezone: "Europe/London"}, "LSE"},
+		{Tick{Type: "CRYPTOCURRENCY", ExchangeName: "CCC", ExchangeTimezone: "UTC"}, "Crypto"},
+		{Tick{Type: "CURRENCY", ExchangeName: "CCY", ExchangeTimezone: "Europe/London"}, ""},
+		{Tick{Type: "FUTURE", ExchangeName: "NYM", ExchangeTimezone: "America/New_York"}, ""},
+	} {
+		got := ""
+		if cal, ok := CalendarOf(&c.tick); ok {
+			got = cal.Name
+		}
+		if got != c.want {
+			t.Errorf("CalendarOf(%s on %s) = %q, want %q", c.tick.Type, c.tick.ExchangeName, got, c.want)
+		}
+	}
+
+	// An hourly EUR/USD bar of a Sunday evening is not out of LSE hours.
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip(err)
+	}
+	tick := Tick{
+		Type:             "CURRENCY",
+		Granularity:      "1h",
+		ExchangeName:     "CCY",
+		ExchangeTimezone: "Europe/London",
+		Timestamp:        Timestamp{time.Date(2023, 7, 9, 23, 0, 0, 0, london)},
+	}
+	if ClassifySession(&tick); tick.Session != SessionRegular {
+		t.Errorf("forex bar classified as %s, want %s", tick.Session, SessionRegular)
+	}
+}
@@ -0,0 +1,453 @@
+package odbc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Sink is a storage backend for downloaded quotes and ticks. Quotes are
+// the values returned by an AssetClass converter and are written into
+// the given table.
+type Sink interface {
+	WriteQuotes(ctx context.Context, table string, quotes []interface{}) error
+	WriteTicks(ctx context.Context, ticks []Tick, mode WriteMode) (WriteStats, error)
+	Flush(ctx context.Context) error
+	Close() error
+}
+
+// WriteMode decides what happens to a tick whose key is already stored.
+type WriteMode int
+
+const (
+	// KeepStored leaves stored bars untouched.
+	KeepStored WriteMode = iota
+	// Upsert replaces stored bars whose prices or volume changed, e.g.
+	// the still forming candle of the current day.
+	Upsert
+	// UpsertResampled replaces only stored bars that were resampled
+	// themselves, so bars derived anew never overwrite downloaded ones.
+	UpsertResampled
+)
+
+// WriteStats counts what WriteTicks did with each tick.
+type WriteStats struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+}
+
+func (s *WriteStats) Add(o WriteStats) {
+	s.Inserted += o.Inserted
+	s.Updated += o.Updated
+	s.Unchanged += o.Unchanged
+}
+
+func (s WriteStats) String() string {
+	return fmt.Sprintf("%d inserted, %d updated, %d unchanged", s.Inserted, s.Updated, s.Unchanged)
+}
+
+// classify decides how a tick is written given the stored bar, if any.
+// A downloaded bar always replaces a resampled one.
+func (s *WriteStats) classify(t, stored *Tick, mode WriteMode) (insert, update bool) {
+	switch {
+	case stored == nil:
+		s.Inserted++
+		return true, false
+	case stored.ResampledFrom != "" && t.ResampledFrom == "",
+		mode == Upsert && !t.SameBar(stored),
+		mode == UpsertResampled && stored.ResampledFrom != "" && !t.SameBar(stored):
+		s.Updated++
+		return false, true
+	}
+	s.Unchanged++
+	return false, false
+}
+
+// uniqueTicks drops the ticks given again for a bar, so every bar is
+// written and counted once: the last one given is upserted, the first
+// one given is kept with KeepStored.
+func uniqueTicks(ticks []Tick, mode WriteMode) []Tick {
+	unique := []Tick{}
+	seen := map[TickKey]int{}
+	for _, t := range ticks {
+		i, ok := seen[t.Key()]
+		switch {
+		case !ok:
+			seen[t.Key()] = len(unique)
+			unique = append(unique, t)
+		case mode != KeepStored:
+			unique[i] = t
+		}
+	}
+	return unique
+}
+
+// TickRangeReader is implemented by sinks that can tell which bars they
+// already hold. TickRange returns the oldest and newest stored
+// timestamp of symbol at granularity; ok is false if there are none.
+type TickRangeReader interface {
+	TickRange(ctx context.Context, symbol, granularity string) (first, last time.Time, ok bool, err error)
+}
+
+// FirstTradeDateReader is implemented by sinks that can tell the first
+// trade date Yahoo reported with the stored bars of symbol; ok is false
+// if none was.
+type FirstTradeDateReader interface {
+	FirstTradeDate(ctx context.Context, symbol string) (date time.Time, ok bool, err error)
+}
+
+// TickReader is implemented by sinks that can read stored bars back.
+// ReadTicks returns all bars of symbol at granularity, oldest first.
+type TickReader interface {
+	ReadTicks(ctx context.Context, symbol, granularity string) ([]Tick, error)
+}
+
+// ValidRangeWriter is implemented by sinks that can record which query
+// ranges the stored bars are valid for. Associations already stored are
+// skipped.
+type ValidRangeWriter interface {
+	WriteValidRanges(ctx context.Context, rs []TickValidRange) error
+}
+
+// CorporateActionStore is implemented by sinks that can store
+// corporate actions. Actions already stored are skipped.
+type CorporateActionStore interface {
+	WriteCorporateActions(ctx context.Context, as []CorporateAction) error
+	ReadCorporateActions(ctx context.Context, symbol string) ([]CorporateAction, error)
+}
+
+// SQLiteColumnType maps a Go field type onto the SQLite column type it
+// is stored as. Decimals are kept as text so no precision is lost to
+// SQLite's floating point affinity.
+func SQLiteColumnType(t reflect.Type) (string, error) {
+	switch t {
+	case decimalType:
+		return "TEXT", nil
+	case timeType, timestampType:
+		return "DATETIME", nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "TEXT", nil
+	case reflect.Bool:
+		return "BOOLEAN", nil
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+		return "INTEGER", nil
+	case reflect.Float64, reflect.Float32:
+		return "REAL", nil
+	}
+	return "", fmt.Errorf("sqlite: no column type for %s", t)
+}
+
+// SQLiteSink writes into a SQLite database, e.g. one opened with the
+// dyn-sqlite3 driver of github.com/jakoblorz/dynsql. Tables are created
+// from the `db` tags on first use.
+type SQLiteSink struct {
+	db        *sqlx.DB
+	batchSize int
+
+	mu      sync.Mutex
+	created map[string]bool
+}
+
+func NewSQLiteSink(db *sqlx.DB) *SQLiteSink {
+	return &SQLiteSink{
+		db:      db,
+		created: map[string]bool{},
+	}
+}
+
+var sqliteDialect = dialect{
+	columnType: SQLiteColumnType,
+	columns: func(ctx context.Context, db *sqlx.DB, table string) (map[string]string, error) {
+		rows := []struct {
+			Name string `db:"name"`
+			Type string `db:"type"`
+		}{}
+		if err := db.SelectContext(ctx, &rows, `SELECT "name", "type" FROM pragma_table_info(?)`, table); err != nil || len(rows) == 0 {
+			return nil, err
+		}
+
+		columns := map[string]string{}
+		for _, r := range rows {
+			columns[r.Name] = r.Type
+		}
+		return columns, nil
+	},
+	indexes: func(ctx context.Context, db *sqlx.DB, table string) (map[string]bool, error) {
+		names := []string{}
+		if err := db.SelectContext(ctx, &names, `SELECT "name" FROM sqlite_master WHERE "type" = 'index' AND "tbl_name" = ?`, table); err != nil {
+			return nil, err
+		}
+
+		indexes := map[string]bool{}
+		for _, n := range names {
+			indexes[n] = true
+		}
+		return indexes, nil
+	},
+	// SQLite cannot change the type of a column, so the Unix seconds of
+	// integer columns are rewritten in place; 0 meant "not reported".
+	convertUnix: func(ctx context.Context, db *sqlx.DB, table, column, columnType string) (string, error) {
+		if !strings.EqualFold(columnType, "INTEGER") {
+			return "", nil
+		}
+
+		var legacy bool
+		err := db.GetContext(ctx, &legacy, fmt.Sprintf(
+			`SELECT EXISTS (SELECT 1 FROM %[1]s WHERE typeof(%[2]s) = 'integer')`,
+			quoteIdentifier(table), quoteIdentifier(column),
+		))
+		if err != nil || !legacy {
+			return "", err
+		}
+		return fmt.Sprintf(
+			`UPDATE %[1]s SET %[2]s = CASE WHEN %[2]s = 0 THEN NULL ELSE strftime('%%Y-%%m-%%d %%H:%%M:%%S+00:00', %[2]s, 'unixepoch') END WHERE typeof(%[2]s) = 'integer'`,
+			quoteIdentifier(table), quoteIdentifier(column),
+		), nil
+	},
+	// Columns with REAL affinity turn decimal text back into floats, and
+	// as SQLite cannot change the type of a column, the table is rebuilt
+	// with the decimals cast to text.
+	floating: func(columnType string) bool {
+		t := strings.ToUpper(columnType)
+		return strings.Contains(t, "REAL") || strings.Contains(t, "FLOA") || strings.Contains(t, "DOUB")
+	},
+	convertDecimals: func(ctx context.Context, db *sqlx.DB, table string, columns []string) ([]string, error) {
+		live := []struct {
+			Name    string         `db:"name"`
+			Type    string         `db:"type"`
+			NotNull bool           `db:"notnull"`
+			Default sql.NullString `db:"dflt_value"`
+		}{}
+		if err := db.SelectContext(ctx, &live, `SELECT "name", "type", "notnull", "dflt_value" FROM pragma_table_info(?) ORDER BY "cid"`, table); err != nil {
+			return nil, err
+		}
+		indexes := []string{}
+		if err := db.SelectContext(ctx, &indexes, `SELECT "sql" FROM sqlite_master WHERE "type" = 'index' AND "tbl_name" = ? AND "sql" IS NOT NULL`, table); err != nil {
+			return nil, err
+		}
+
+		convert := map[string]bool{}
+		for _, c := range columns {
+			convert[c] = true
+		}
+		defs, values := []string{}, []string{}
+		for _, c := range live {
+			def, value := fmt.Sprintf("%s %s", quoteIdentifier(c.Name), c.Type), quoteIdentifier(c.Name)
+			if convert[c.Name] {
+				def = fmt.Sprintf("%s TEXT", quoteIdentifier(c.Name))
+				value = fmt.Sprintf("CAST(%s AS TEXT)", quoteIdentifier(c.Name))
+			}
+			if c.NotNull {
+				def += " NOT NULL"
+			}
+			if c.Default.Valid {
+				def += " DEFAULT " + c.Default.String
+			}
+			defs = append(defs, def)
+			values = append(values, value)
+		}
+
+		rebuilt := quoteIdentifier(table + "_migrating")
+		return append([]string{
+			fmt.Sprintf("CREATE TABLE %s (%s)", rebuilt, strings.Join(defs, ", ")),
+			fmt.Sprintf("INSERT INTO %s SELECT %s FROM %s", rebuilt, strings.Join(values, ", "), quoteIdentifier(table)),
+			fmt.Sprintf("DROP TABLE %s", quoteIdentifier(table)),
+			fmt.Sprintf("ALTER TABLE %s RENAME TO %s", rebuilt, quoteIdentifier(table)),
+		}, indexes...), nil
+	},
+	dedupe: func(table string, columns []string) string {
+		quoted := make([]string, len(columns))
+		for i, c := range columns {
+			quoted[i] = quoteIdentifier(c)
+		}
+		return fmt.Sprintf(
+			`DELETE FROM %[1]s WHERE rowid NOT IN (SELECT MAX(rowid) FROM %[1]s GROUP BY %[2]s)`,
+			quoteIdentifier(table), strings.Join(quoted, ", "),
+		)
+	},
+}
+
+// ensureTable creates table the first time it is used if it does not
+// exist yet. Tables that do are left as they are; bringing them in line
+// with the structs is up to Migrate.
+func (s *SQLiteSink) ensureTable(ctx context.Context, table string) error {
+	if s.created[table] {
+		return nil
+	}
+
+	schema, ok := StoredTables()[table]
+	if !ok {
+		return CheckTable(table)
+	}
+	if err := createMissingTable(ctx, s.db, sqliteDialect, table, schema); err != nil {
+		return err
+	}
+	s.created[table] = true
+	return nil
+}
+
+func (s *SQLiteSink) SchemaVersion(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return schemaVersion(ctx, s.db, sqliteDialect)
+}
+
+func (s *SQLiteSink) PlanMigrations(ctx context.Context) ([]Migration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return planMigrations(ctx, s.db, sqliteDialect, StoredTables())
+}
+
+func (s *SQLiteSink) Migrate(ctx context.Context, ms []Migration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return migrate(ctx, s.db, sqliteDialect, ms)
+}
+
+func (s *SQLiteSink) SetBatchSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batchSize = n
+}
+
+func (s *SQLiteSink) WriteQuotes(ctx context.Context, table string, quotes []interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureTable(ctx, table); err != nil {
+		return err
+	}
+	return writeQuotes(ctx, s.db, table, quotes, s.batchSize)
+}
+
+func (s *SQLiteSink) WriteTicks(ctx context.Context, ticks []Tick, mode WriteMode) (WriteStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureTable(ctx, TickTableName); err != nil {
+		return WriteStats{}, err
+	}
+	return writeTicks(ctx, s.db, ticks, mode, s.batchSize)
+}
+
+func (s *SQLiteSink) WriteValidRanges(ctx context.Context, rs []TickValidRange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureTable(ctx, TickValidRangeTableName); err != nil {
+		return err
+	}
+	return writeValidRanges(ctx, s.db, rs, s.batchSize)
+}
+
+func (s *SQLiteSink) WriteCorporateActions(ctx context.Context, as []CorporateAction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureTable(ctx, CorporateActionTableName); err != nil {
+		return err
+	}
+	return writeCorporateActions(ctx, s.db, as, s.batchSize)
+}
+
+func (s *SQLiteSink) ReadCorporateActions(ctx context.Context, symbol string) ([]CorporateAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureTable(ctx, CorporateActionTableName); err != nil {
+		return nil, err
+	}
+	return readCorporateActions(ctx, s.db, symbol)
+}
+
+func (s *SQLiteSink) WatchlistNames(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureTable(ctx, WatchlistTableName); err != nil {
+		return nil, err
+	}
+	return watchlistNames(ctx, s.db)
+}
+
+func (s *SQLiteSink) ReadWatchlist(ctx context.Context, name string) ([]WatchlistMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureTable(ctx, WatchlistTableName); err != nil {
+		return nil, err
+	}
+	return readWatchlist(ctx, s.db, name)
+}
+
+func (s *SQLiteSink) AddToWatchlist(ctx context.Context, ms []WatchlistMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureTable(ctx, WatchlistTableName); err != nil {
+		return err
+	}
+	return addToWatchlist(ctx, s.db, ms)
+}
+
+func (s *SQLiteSink) RemoveFromWatchlist(ctx context.Context, name string, symbols []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureTable(ctx, WatchlistTableName); err != nil {
+		return err
+	}
+	return removeFromWatchlist(ctx, s.db, name, symbols)
+}
+
+func (s *SQLiteSink) TickRange(ctx context.Context, symbol, granularity string) (first, last time.Time, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = s.ensureTable(ctx, TickTableName); err != nil {
+		return
+	}
+	return tickRange(ctx, s.db, symbol, granularity)
+}
+
+func (s *SQLiteSink) FirstTradeDate(ctx context.Context, symbol string) (date time.Time, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = s.ensureTable(ctx, TickTableName); err != nil {
+		return
+	}
+	return firstTradeDate(ctx, s.db, symbol)
+}
+
+func (s *SQLiteSink) ReadTicks(ctx context.Context, symbol, granularity string) ([]Tick, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureTable(ctx, TickTableName); err != nil {
+		return nil, err
+	}
+	return readTicks(ctx, s.db, symbol, granularity)
+}
+
+func (s *SQLiteSink) Flush(ctx context.Context) error {
+	return nil
+}
+
+func (s *SQLiteSink) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,445 @@
+package odbc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// StoredTables returns the tables rows may be written to, keyed by
+// table name with a zero value of the stored struct: the tables of all
+// registered asset classes, the tick table with its valid ranges, the
+// corporate actions and the watchlists.
+func StoredTables() map[string]interface{} {
+	tables := map[string]interface{}{
+		TickTableName:            Tick{},
+		TickValidRangeTableName:  TickValidRange{},
+		CorporateActionTableName: CorporateAction{},
+		WatchlistTableName:       WatchlistMember{},
+	}
+	for _, c := range AssetClasses() {
+		if c.Schema != nil {
+			tables[c.TableName] = c.Schema
+		}
+	}
+	return tables
+}
+
+// CheckTable fails unless table is one of StoredTables.
+func CheckTable(table string) error {
+	if _, ok := StoredTables()[table]; !ok {
+		return fmt.Errorf("refusing to write to unregistered table %q", table)
+	}
+	return nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func createTableStatement(table string, v interface{}, columnType func(reflect.Type) (string, error)) (string, error) {
+	defs := []string{}
+	for _, c := range ColumnsOf(v) {
+		t, err := columnType(c.Type)
+		if err != nil {
+			return "", fmt.Errorf("%s.%s: %s", table, c.Name, err)
+		}
+		defs = append(defs, fmt.Sprintf("%s %s", quoteIdentifier(c.Name), t))
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s\n);", quoteIdentifier(table), strings.Join(defs, ",\n\t")), nil
+}
+
+// insertStatement returns a named INSERT for v into table. Identifiers
+// are quoted and every value is bound as a parameter.
+func insertStatement(table string, v interface{}) (string, error) {
+	head, err := insertHead(table, v)
+	if err != nil {
+		return "", err
+	}
+
+	names := ColumnNames(v)
+	params := make([]string, len(names))
+	for i, n := range names {
+		params[i] = ":" + n
+	}
+	return fmt.Sprintf("%s VALUES (%s)", head, strings.Join(params, ", ")), nil
+}
+
+// insertHead returns the INSERT of v into table up to its VALUES.
+func insertHead(table string, v interface{}) (string, error) {
+	if err := CheckTable(table); err != nil {
+		return "", err
+	}
+
+	names := ColumnNames(v)
+	columns := make([]string, len(names))
+	for i, n := range names {
+		columns[i] = quoteIdentifier(n)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s)", quoteIdentifier(table), strings.Join(columns, ", ")), nil
+}
+
+func insertRow(ctx context.Context, db *sqlx.DB, table string, v interface{}) error {
+	stmt, err := insertStatement(table, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.NamedExecContext(ctx, stmt, v)
+	return err
+}
+
+// DefaultBatchSize is the number of rows inserted per statement unless a
+// sink is told otherwise.
+const DefaultBatchSize = 200
+
+// BatchSizer is implemented by sinks that insert rows in batches. A size
+// of 0 or less selects DefaultBatchSize.
+type BatchSizer interface {
+	SetBatchSize(n int)
+}
+
+func batchSize(n int) int {
+	if n <= 0 {
+		return DefaultBatchSize
+	}
+	return n
+}
+
+// inTx runs fn in a transaction that is only committed if fn succeeds.
+func inTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// maxParams is the number of parameters a statement of driver may bind:
+// 65535 in Postgres and 32766 in SQLite since 3.32.
+func maxParams(driver string) int {
+	if driver == "postgres" {
+		return 65535
+	}
+	return 32766
+}
+
+// batchStatement appends the ? parameters of rows rows of columns
+// columns each and suffix to the INSERT head.
+func batchStatement(head string, columns, rows int, suffix string) string {
+	params := "(" + strings.TrimSuffix(strings.Repeat("?, ", columns), ", ") + ")"
+	return head + " VALUES " + strings.TrimSuffix(strings.Repeat(params+", ", rows), ", ") + suffix
+}
+
+// insertBatches inserts rows, values of the same struct, into table with
+// INSERTs of up to size rows each, followed by suffix. Batches shrink to
+// fit the parameters a statement may bind. Each statement is prepared
+// once per row count.
+func insertBatches(ctx context.Context, tx *sqlx.Tx, table string, rows []interface{}, size int, suffix string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	columns := len(ColumnNames(rows[0]))
+	if limit := maxParams(tx.DriverName()) / columns; size > limit {
+		size = limit
+	}
+	head, err := insertHead(table, rows[0])
+	if err != nil {
+		return err
+	}
+	named, err := insertStatement(table, rows[0])
+	if err != nil {
+		return err
+	}
+	stmts := map[int]*sqlx.Stmt{}
+	defer func() {
+		for _, stmt := range stmts {
+			stmt.Close()
+		}
+	}()
+
+	for start := 0; start < len(rows); start += size {
+		batch := rows[start:]
+		if len(batch) > size {
+			batch = batch[:size]
+		}
+
+		stmt, ok := stmts[len(batch)]
+		if !ok {
+			if stmt, err = tx.PreparexContext(ctx, tx.Rebind(batchStatement(head, columns, len(batch), suffix))); err != nil {
+				return err
+			}
+			stmts[len(batch)] = stmt
+		}
+
+		args := []interface{}{}
+		for _, row := range batch {
+			_, a, err := sqlx.Named(named, row)
+			if err != nil {
+				return err
+			}
+			args = append(args, a...)
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeQuotes inserts quotes into table in a single transaction.
+func writeQuotes(ctx context.Context, db *sqlx.DB, table string, quotes []interface{}, size int) error {
+	if err := CheckTable(table); err != nil {
+		return err
+	}
+	return inTx(ctx, db, func(tx *sqlx.Tx) error {
+		return insertBatches(ctx, tx, table, quotes, batchSize(size), "")
+	})
+}
+
+func tickRange(ctx context.Context, db *sqlx.DB, symbol, granularity string) (first, last time.Time, ok bool, err error) {
+	var bounds struct {
+		First Timestamp `db:"first"`
+		Last  Timestamp `db:"last"`
+	}
+
+	err = db.GetContext(ctx, &bounds, db.Rebind(fmt.Sprintf(
+		`SELECT MIN("timestamp") AS first, MAX("timestamp") AS last FROM %s WHERE "symbol" = ? AND "granularity" = ?`,
+		quoteIdentifier(TickTableName),
+	)), symbol, granularity)
+	if err != nil || bounds.Last.IsZero() {
+		return
+	}
+	return bounds.First.Time, bounds.Last.Time, true, nil
+}
+
+func firstTradeDate(ctx context.Context, db *sqlx.DB, symbol string) (date time.Time, ok bool, err error) {
+	var first Timestamp
+	err = db.GetContext(ctx, &first, db.Rebind(fmt.Sprintf(
+		`SELECT MIN("first_trade_day") FROM %s WHERE "symbol" = ?`,
+		quoteIdentifier(TickTableName),
+	)), symbol)
+	if err != nil || first.IsZero() {
+		return
+	}
+	return first.Time, true, nil
+}
+
+func readTicks(ctx context.Context, db *sqlx.DB, symbol, granularity string) ([]Tick, error) {
+	names := ColumnNames(Tick{})
+	columns := make([]string, len(names))
+	for i, n := range names {
+		columns[i] = quoteIdentifier(n)
+	}
+
+	ticks := []Tick{}
+	err := db.SelectContext(ctx, &ticks, db.Rebind(fmt.Sprintf(
+		`SELECT %s FROM %s WHERE "symbol" = ? AND "granularity" = ? ORDER BY "timestamp"`,
+		strings.Join(columns, ", "),
+		quoteIdentifier(TickTableName),
+	)), symbol, granularity)
+	for i := range ticks {
+		ticks[i].localize()
+	}
+	return ticks, err
+}
+
+func tickKeyCondition() string {
+	conds := make([]string, len(TickKeyColumns))
+	for i, c := range TickKeyColumns {
+		conds[i] = fmt.Sprintf("%s = :%s", quoteIdentifier(c), c)
+	}
+	return strings.Join(conds, " AND ")
+}
+
+func tickUpdateStatement() string {
+	key := map[string]bool{}
+	for _, c := range TickKeyColumns {
+		key[c] = true
+	}
+
+	sets := []string{}
+	for _, c := range ColumnNames(Tick{}) {
+		if !key[c] {
+			sets = append(sets, fmt.Sprintf("%s = :%s", quoteIdentifier(c), c))
+		}
+	}
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteIdentifier(TickTableName), strings.Join(sets, ", "), tickKeyCondition())
+}
+
+// storedTicks returns the stored bars of the series of ticks, keyed by
+// their natural key, reading each series between its first and last
+// timestamp in one query.
+func storedTicks(ctx context.Context, tx *sqlx.Tx, ticks []Tick) (map[TickKey]*Tick, error) {
+	type series struct{ symbol, granularity string }
+	bounds := map[series][2]Timestamp{}
+	for _, t := range ticks {
+		k := series{t.Symbol, t.Granularity}
+		b, ok := bounds[k]
+		if !ok || t.Timestamp.Before(b[0].Time) {
+			b[0] = t.Timestamp
+		}
+		if !ok || t.Timestamp.After(b[1].Time) {
+			b[1] = t.Timestamp
+		}
+		bounds[k] = b
+	}
+
+	query := tx.Rebind(fmt.Sprintf(
+		`SELECT "symbol", "granularity", "timestamp", "open", "low", "high", "close", "adj_close", "volume", "resampled_from" FROM %s WHERE "symbol" = ? AND "granularity" = ? AND "timestamp" >= ? AND "timestamp" <= ?`,
+		quoteIdentifier(TickTableName),
+	))
+	stored := map[TickKey]*Tick{}
+	for k, b := range bounds {
+		found := []Tick{}
+		if err := tx.SelectContext(ctx, &found, query, k.symbol, k.granularity, b[0], b[1]); err != nil {
+			return nil, err
+		}
+		for i := range found {
+			stored[found[i].Key()] = &found[i]
+		}
+	}
+	return stored, nil
+}
+
+// writeTicks writes ticks in a single transaction, so a series is either
+// stored completely or not at all. The stored bars of each series are
+// read at once; changed ones are updated with a prepared statement and
+// new ones are inserted in batches of size.
+func writeTicks(ctx context.Context, db *sqlx.DB, ticks []Tick, mode WriteMode, size int) (WriteStats, error) {
+	stats := WriteStats{}
+	ticks = uniqueTicks(ticks, mode)
+	err := inTx(ctx, db, func(tx *sqlx.Tx) error {
+		stored, err := storedTicks(ctx, tx, ticks)
+		if err != nil {
+			return err
+		}
+		updateStmt, err := tx.PrepareNamedContext(ctx, tickUpdateStatement())
+		if err != nil {
+			return err
+		}
+		defer updateStmt.Close()
+
+		inserts := []interface{}{}
+		for i := range ticks {
+			t := &ticks[i]
+			insert, update := stats.classify(t, stored[t.Key()], mode)
+			switch {
+			case insert:
+				inserts = append(inserts, t)
+			case update:
+				if _, err := updateStmt.ExecContext(ctx, t); err != nil {
+					return err
+				}
+			}
+		}
+		return insertBatches(ctx, tx, TickTableName, inserts, batchSize(size), "")
+	})
+	if err != nil {
+		return WriteStats{}, err
+	}
+	return stats, nil
+}
+
+// insertNewRows inserts the rows of table in a single transaction,
+// skipping those with a natural key that is stored already.
+func insertNewRows(ctx context.Context, db *sqlx.DB, table string, rows []interface{}, size int) error {
+	return inTx(ctx, db, func(tx *sqlx.Tx) error {
+		return insertBatches(ctx, tx, table, rows, batchSize(size), " ON CONFLICT DO NOTHING")
+	})
+}
+
+func writeValidRanges(ctx context.Context, db *sqlx.DB, rs []TickValidRange, size int) error {
+	rows := make([]interface{}, len(rs))
+	for i := range rs {
+		rows[i] = rs[i]
+	}
+	return insertNewRows(ctx, db, TickValidRangeTableName, rows, size)
+}
+
+func writeCorporateActions(ctx context.Context, db *sqlx.DB, as []CorporateAction, size int) error {
+	rows := make([]interface{}, len(as))
+	for i := range as {
+		rows[i] = as[i]
+	}
+	return insertNewRows(ctx, db, CorporateActionTableName, rows, size)
+}
+
+func readCorporateActions(ctx context.Context, db *sqlx.DB, symbol string) ([]CorporateAction, error) {
+	names := ColumnNames(CorporateAction{})
+	columns := make([]string, len(names))
+	for i, n := range names {
+		columns[i] = quoteIdentifier(n)
+	}
+
+	as := []CorporateAction{}
+	err := db.SelectContext(ctx, &as, db.Rebind(fmt.Sprintf(
+		`SELECT %s FROM %s WHERE "symbol" = ? ORDER BY "timestamp"`,
+		strings.Join(columns, ", "),
+		quoteIdentifier(CorporateActionTableName),
+	)), symbol)
+	return as, err
+}
+
+func watchlistNames(ctx context.Context, db *sqlx.DB) ([]string, error) {
+	names := []string{}
+	err := db.SelectContext(ctx, &names, fmt.Sprintf(
+		`SELECT DISTINCT "watchlist" FROM %s ORDER BY "watchlist"`,
+		quoteIdentifier(WatchlistTableName),
+	))
+	return names, err
+}
+
+func readWatchlist(ctx context.Context, db *sqlx.DB, name string) ([]WatchlistMember, error) {
+	names := ColumnNames(WatchlistMember{})
+	columns := make([]string, len(names))
+	for i, n := range names {
+		columns[i] = quoteIdentifier(n)
+	}
+
+	ms := []WatchlistMember{}
+	err := db.SelectContext(ctx, &ms, db.Rebind(fmt.Sprintf(
+		`SELECT %s FROM %s WHERE "watchlist" = ? ORDER BY "symbol"`,
+		strings.Join(columns, ", "),
+		quoteIdentifier(WatchlistTableName),
+	)), name)
+	return ms, err
+}
+
+func addToWatchlist(ctx context.Context, db *sqlx.DB, ms []WatchlistMember) error {
+	stmt, err := insertStatement(WatchlistTableName, WatchlistMember{})
+	if err != nil {
+		return err
+	}
+	stmt += ` ON CONFLICT ("watchlist", "symbol") DO UPDATE SET "class" = excluded."class", "intervals" = excluded."intervals"`
+
+	for _, m := range ms {
+		if _, err := db.NamedExecContext(ctx, stmt, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeFromWatchlist(ctx context.Context, db *sqlx.DB, name string, symbols []string) error {
+	for _, symbol := range symbols {
+		_, err := db.ExecContext(ctx, db.Rebind(fmt.Sprintf(
+			`DELETE FROM %s WHERE "watchlist" = ? AND "symbol" = ?`,
+			quoteIdentifier(WatchlistTableName),
+		)), name, symbol)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
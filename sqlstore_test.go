@@ -0,0 +1,211 @@
+package odbc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piquette/finance-go"
+	"github.com/shopspring/decimal"
+)
+
+func TestInsertStatement(t *testing.T) {
+	stmt, err := insertStatement("equity", Equity{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stmt, `INSERT INTO "equity" ("inserted_at", "market_id",`) {
+		t.Errorf("unexpected statement %s", stmt)
+	}
+	if !strings.Contains(stmt, ":short_name") {
+		t.Errorf("short_name is not bound as a parameter: %s", stmt)
+	}
+
+	for _, table := range []string{"rogue", `equity"; DROP TABLE "ticks`} {
+		if _, err := insertStatement(table, Equity{}); err == nil {
+			t.Errorf("insert into %q was not rejected", table)
+		}
+	}
+}
+
+func TestInsertBatchesParameterLimit(t *testing.T) {
+	db := openSQLite(t)
+	s := NewSQLiteSink(db)
+	s.SetBatchSize(10000)
+
+	// more parameters in one batch than SQLite binds in a statement
+	rows := 2 * maxParams("dyn-sqlite3") / len(ColumnNames(Equity{}))
+	quotes := []interface{}{}
+	for i := 0; i < rows; i++ {
+		e := Equity{}
+		e.Symbol = fmt.Sprintf("S%d", i)
+		quotes = append(quotes, e)
+	}
+	if err := s.WriteQuotes(context.Background(), "equity", quotes); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.Get(&n, `SELECT COUNT(*) FROM "equity"`); err != nil || n != rows {
+		t.Errorf("stored %d of %d rows, %v", n, rows, err)
+	}
+}
+
+func TestWriteTicks(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	s := NewSQLiteSink(db)
+	s.SetBatchSize(2)
+
+	bar := func(day int, close string) Tick {
+		return Tick{
+			Symbol:      "AAPL",
+			Granularity: "1d",
+			Timestamp:   NewTimestamp(1701095400+day*86400, time.UTC),
+			Close:       decimal.RequireFromString(close),
+		}
+	}
+	closes := func() string {
+		ticks, err := s.ReadTicks(ctx, "AAPL", "1d")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs := []string{}
+		for _, tick := range ticks {
+			cs = append(cs, tick.Close.String())
+		}
+		return strings.Join(cs, " ")
+	}
+
+	for _, c := range []struct {
+		name   string
+		ticks  []Tick
+		mode   WriteMode
+		want   WriteStats
+		closes string
+	}{
+		{"new bars", []Tick{bar(0, "1"), bar(1, "2"), bar(2, "3")}, KeepStored, WriteStats{Inserted: 3}, "1 2 3"},
+		{"revised bars kept", []Tick{bar(0, "1"), bar(1, "2.5"), bar(3, "4"), bar(3, "4")}, KeepStored, WriteStats{Inserted: 1, Unchanged: 2}, "1 2 3 4"},
+		{"revised bars upserted", []Tick{bar(0, "1"), bar(1, "2.5"), bar(4, "5"), bar(4, "5.5")}, Upsert, WriteStats{Inserted: 1, Updated: 1, Unchanged: 1}, "1 2.5 3 4 5.5"},
+	} {
+		stats, err := s.WriteTicks(ctx, c.ticks, c.mode)
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		if stats != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, stats, c.want)
+		}
+		if got := closes(); got != c.closes {
+			t.Errorf("%s: stored closes %s, want %s", c.name, got, c.closes)
+		}
+	}
+
+	if _, ok, err := s.FirstTradeDate(ctx, "AAPL"); ok || err != nil {
+		t.Errorf("found a first trade date none was stored with, %v", err)
+	}
+	listed := bar(5, "6")
+	listed.FirstTradeDate = NewTimestamp(345479400, time.UTC)
+	if _, err := s.WriteTicks(ctx, []Tick{listed}, KeepStored); err != nil {
+		t.Fatal(err)
+	}
+	if date, ok, err := s.FirstTradeDate(ctx, "AAPL"); !ok || err != nil || !date.Equal(listed.FirstTradeDate.Time) {
+		t.Errorf("first trade date %s, %v, want %s", date, err, listed.FirstTradeDate)
+	}
+}
+
+func TestWriteValidRanges(t *testing.T) {
+	ctx := context.Background()
+	tick := NewTickFromAPI(&MetaTick{ChartMeta: finance.ChartMeta{
+		Symbol:          "AAPL",
+		DataGranularity: "1d",
+		ValidRanges:     []string{"1mo", "5y", "max"},
+	}})
+	if tick.Granularity != "1d" {
+		t.Fatalf("bar stored at %s, want its interval 1d", tick.Granularity)
+	}
+	rs := tick.ValidRanges()
+	if got := fmt.Sprint(rs); got != "[{AAPL 1d 1mo} {AAPL 1d 5y} {AAPL 1d max}]" {
+		t.Fatalf("fanned out into %s", got)
+	}
+
+	db := openSQLite(t)
+	s := NewSQLiteSink(db)
+	dir := t.TempDir()
+	fs, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []ValidRangeWriter{s, fs} {
+		// Ranges written again are stored once.
+		for _, rs := range [][]TickValidRange{rs, rs[1:], append(rs, TickValidRange{"AAPL", "1d", "ytd"})} {
+			if err := w.WriteValidRanges(ctx, rs); err != nil {
+				t.Fatalf("%T: %s", w, err)
+			}
+		}
+	}
+	if err := fs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := db.Get(&n, `SELECT COUNT(*) FROM "tick_valid_ranges" WHERE "symbol" = 'AAPL' AND "granularity" = '1d'`); err != nil || n != 4 {
+		t.Errorf("stored %d valid ranges in SQLite, want 4, %v", n, err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, TickValidRangeTableName+".jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), "\n"); n != 4 {
+		t.Errorf("stored %d valid ranges in files, want 4", n)
+	}
+}
+
+func TestWriteResampledTicks(t *testing.T) {
+	ctx := context.Background()
+	s := NewSQLiteSink(openSQLite(t))
+
+	bar := func(month time.Month, close, from string) Tick {
+		return Tick{
+			Symbol:        "AAPL",
+			Granularity:   "1mo",
+			Timestamp:     Timestamp{time.Date(2023, month, 1, 0, 0, 0, 0, time.UTC)},
+			Close:         decimal.RequireFromString(close),
+			ResampledFrom: from,
+		}
+	}
+	for _, c := range []struct {
+		name  string
+		ticks []Tick
+		mode  WriteMode
+		want  WriteStats
+	}{
+		{"downloaded", []Tick{bar(1, "1", "")}, KeepStored, WriteStats{Inserted: 1}},
+		{"resampled", []Tick{bar(1, "1.5", "1d"), bar(2, "2", "1d")}, UpsertResampled, WriteStats{Inserted: 1, Unchanged: 1}},
+		{"resampled again", []Tick{bar(1, "1.5", "1d"), bar(2, "2.5", "1d")}, UpsertResampled, WriteStats{Updated: 1, Unchanged: 1}},
+		{"downloaded later", []Tick{bar(2, "2.5", "")}, KeepStored, WriteStats{Updated: 1}},
+		{"resampled over downloaded", []Tick{bar(2, "3", "1d")}, UpsertResampled, WriteStats{Unchanged: 1}},
+	} {
+		stats, err := s.WriteTicks(ctx, c.ticks, c.mode)
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		if stats != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, stats, c.want)
+		}
+	}
+
+	ticks, err := s.ReadTicks(ctx, "AAPL", "1mo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, tick := range ticks {
+		got = append(got, tick.Close.String()+"/"+tick.ResampledFrom)
+	}
+	if fmt.Sprint(got) != "[1/ 2.5/]" {
+		t.Errorf("stored %v, want the downloaded bars", got)
+	}
+}
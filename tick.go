@@ -7,6 +7,11 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const (
+	TickTableName           = "ticks"
+	TickValidRangeTableName = "tick_valid_ranges"
+)
+
 type MetaTick struct {
 	yfin.ChartMeta
 	yfin.ChartBar
@@ -22,96 +27,155 @@ type Tick struct {
 	AdjClose  decimal.Decimal `db:"adj_close" json:"adj_close"`
 	PrvClose  decimal.Decimal `db:"prv_close" json:"prv_close"`
 	Volume    int             `db:"volume" json:"volume"`
-	Timestamp int             `db:"timestamp" json:"timestamp"`
+	Timestamp Timestamp       `db:"timestamp" json:"timestamp"`
 	Currency  string          `db:"currency" json:"currency"`
 	Symbol    string          `db:"symbol" json:"symbol"`
 	Type      string          `db:"type" json:"type"`
 
-	FirstTradeDate   int    `db:"first_trade_day" json:"first_trade_day"`
-	GMTOffset        int    `db:"gmt_offset" json:"gmt_offset"`
-	Timezone         string `db:"timezone" json:"timezone"`
-	ExchangeName     string `db:"exchange_name" json:"exchange_name"`
-	ExchangeTimezone string `db:"exchange_timezone" json:"exchange_timezone"`
+	FirstTradeDate   Timestamp `db:"first_trade_day" json:"first_trade_day"`
+	GMTOffset        int       `db:"gmt_offset" json:"gmt_offset"`
+	Timezone         string    `db:"timezone" json:"timezone"`
+	ExchangeName     string    `db:"exchange_name" json:"exchange_name"`
+	ExchangeTimezone string    `db:"exchange_timezone" json:"exchange_timezone"`
 
-	PreTimezone  string `db:"pre_timezone" json:"pre_timezone"`
-	PreStart     int    `db:"pre_start" json:"pre_start"`
-	PreEnd       int    `db:"pre_end" json:"pre_end"`
-	PreGMTOffset int    `db:"pre_gmt_offset" json:"pre_gmt_offset"`
+	PreTimezone  string    `db:"pre_timezone" json:"pre_timezone"`
+	PreStart     Timestamp `db:"pre_start" json:"pre_start"`
+	PreEnd       Timestamp `db:"pre_end" json:"pre_end"`
+	PreGMTOffset int       `db:"pre_gmt_offset" json:"pre_gmt_offset"`
 
-	RegularTimezone  string `db:"regular_timezone" json:"regular_timezone"`
-	RegularStart     int    `db:"regular_start" json:"regular_start"`
-	RegularEnd       int    `db:"regular_end" json:"regular_end"`
-	RegularGMTOffset int    `db:"regular_gmt_offset" json:"regular_gmt_offset"`
+	RegularTimezone  string    `db:"regular_timezone" json:"regular_timezone"`
+	RegularStart     Timestamp `db:"regular_start" json:"regular_start"`
+	RegularEnd       Timestamp `db:"regular_end" json:"regular_end"`
+	RegularGMTOffset int       `db:"regular_gmt_offset" json:"regular_gmt_offset"`
 
-	PostTimezone  string `db:"post_timezone" json:"post_timezone"`
-	PostStart     int    `db:"post_start" json:"post_start"`
-	PostEnd       int    `db:"post_end" json:"post_end"`
-	PostGMTOffset int    `db:"post_gmt_offset" json:"post_gmt_offset"`
+	PostTimezone  string    `db:"post_timezone" json:"post_timezone"`
+	PostStart     Timestamp `db:"post_start" json:"post_start"`
+	PostEnd       Timestamp `db:"post_end" json:"post_end"`
+	PostGMTOffset int       `db:"post_gmt_offset" json:"post_gmt_offset"`
 
 	Granularity string   `db:"granularity" json:"granularity"`
+	Session     string   `db:"session" json:"session"`
 	Ranges      []string `db:"-" json:"-"`
+
+	// ResampledFrom is the granularity a bar was derived from by
+	// Resample; it is empty for downloaded bars.
+	ResampledFrom string `db:"resampled_from" json:"resampled_from"`
 }
 
-func (t *Tick) PermutateGranularity() []Tick {
-	ts := []Tick{}
-	foundDuplicateOriginGranularity := false
-	for _, r := range t.Ranges {
-		if !foundDuplicateOriginGranularity && r == t.Granularity {
-			foundDuplicateOriginGranularity = true
-		}
+// TickKeyColumns is the natural key of the tick table: a bar is
+// identified by its symbol, granularity and timestamp.
+var TickKeyColumns = []string{"symbol", "granularity", "timestamp"}
 
-		t2 := *t
-		t2.Granularity = r
+// TickKey identifies a bar; Timestamp is in Unix seconds, so the key
+// does not depend on the location the bar's time is in.
+type TickKey struct {
+	Symbol      string
+	Granularity string
+	Timestamp   int64
+}
 
-		ts = append(ts, t2)
+func (t *Tick) Key() TickKey {
+	return TickKey{
+		Symbol:      t.Symbol,
+		Granularity: t.Granularity,
+		Timestamp:   t.Timestamp.Unix(),
 	}
-	if foundDuplicateOriginGranularity == false {
-		ts = append(ts, *t)
+}
+
+// Location returns the exchange timezone of t.
+func (t *Tick) Location() *time.Location {
+	return exchangeLocation(t.ExchangeTimezone, t.GMTOffset)
+}
+
+// localize moves all times of t into its exchange timezone, which is
+// lost when they are stored.
+func (t *Tick) localize() {
+	loc := t.Location()
+	for _, ts := range []*Timestamp{&t.Timestamp, &t.FirstTradeDate, &t.PreStart, &t.PreEnd, &t.RegularStart, &t.RegularEnd, &t.PostStart, &t.PostEnd} {
+		*ts = ts.In(loc)
+	}
+}
+
+// SameBar reports whether t and o carry the same prices and volume.
+func (t *Tick) SameBar(o *Tick) bool {
+	return t.Open.Equal(o.Open) &&
+		t.Low.Equal(o.Low) &&
+		t.High.Equal(o.High) &&
+		t.Close.Equal(o.Close) &&
+		t.AdjClose.Equal(o.AdjClose) &&
+		t.Volume == o.Volume
+}
+
+// TickValidRange records that the bars of Symbol at Granularity can be
+// queried over ValidRange (e.g. 1d bars over 1mo, 5y or max). It is
+// stored separately from the bars themselves.
+type TickValidRange struct {
+	Symbol      string `db:"symbol" json:"symbol"`
+	Granularity string `db:"granularity" json:"granularity"`
+	ValidRange  string `db:"valid_range" json:"valid_range"`
+}
+
+// TickValidRangeKeyColumns is the natural key of the valid range table.
+var TickValidRangeKeyColumns = []string{"symbol", "granularity", "valid_range"}
+
+// ValidRanges fans t out into one association per range its bar
+// interval is valid for.
+func (t *Tick) ValidRanges() []TickValidRange {
+	rs := make([]TickValidRange, len(t.Ranges))
+	for i, r := range t.Ranges {
+		rs[i] = TickValidRange{
+			Symbol:      t.Symbol,
+			Granularity: t.Granularity,
+			ValidRange:  r,
+		}
 	}
-	return ts
+	return rs
 }
 
 func NewTickFromAPI(x *MetaTick) Tick {
 	t, m := x.ChartBar, x.ChartMeta
-	return Tick{
+	loc := exchangeLocation(m.ExchangeTimezoneName, m.Gmtoffset)
+	tick := Tick{
 		DBEntry: DBEntry{
 			InsertedAt: time.Now().UTC(),
 		},
 
-		Open:      t.Open,
-		Low:       t.Low,
-		High:      t.High,
-		Close:     t.Close,
-		AdjClose:  t.AdjClose,
-		PrvClose:  decimal.NewFromFloat(m.ChartPreviousClose),
+		Open:      t.Open.Round(CurrencyPrecision(m.Currency)),
+		Low:       t.Low.Round(CurrencyPrecision(m.Currency)),
+		High:      t.High.Round(CurrencyPrecision(m.Currency)),
+		Close:     t.Close.Round(CurrencyPrecision(m.Currency)),
+		AdjClose:  t.AdjClose.Round(CurrencyPrecision(m.Currency)),
+		PrvClose:  NewPrice(m.ChartPreviousClose, m.Currency),
 		Volume:    t.Volume,
-		Timestamp: t.Timestamp,
+		Timestamp: NewTimestamp(t.Timestamp, loc),
 		Currency:  m.Currency,
 		Symbol:    m.Symbol,
 		Type:      string(m.QuoteType),
 
-		FirstTradeDate:   m.FirstTradeDate,
+		FirstTradeDate:   NewTimestamp(m.FirstTradeDate, loc),
 		GMTOffset:        m.Gmtoffset,
 		Timezone:         m.Timezone,
 		ExchangeName:     m.ExchangeName,
 		ExchangeTimezone: m.ExchangeTimezoneName,
 
 		PreTimezone:  m.CurrentTradingPeriod.Pre.Timezone,
-		PreStart:     m.CurrentTradingPeriod.Pre.Start,
-		PreEnd:       m.CurrentTradingPeriod.Pre.End,
+		PreStart:     NewTimestamp(m.CurrentTradingPeriod.Pre.Start, loc),
+		PreEnd:       NewTimestamp(m.CurrentTradingPeriod.Pre.End, loc),
 		PreGMTOffset: m.CurrentTradingPeriod.Pre.Gmtoffset,
 
 		RegularTimezone:  m.CurrentTradingPeriod.Regular.Timezone,
-		RegularStart:     m.CurrentTradingPeriod.Regular.Start,
-		RegularEnd:       m.CurrentTradingPeriod.Regular.End,
+		RegularStart:     NewTimestamp(m.CurrentTradingPeriod.Regular.Start, loc),
+		RegularEnd:       NewTimestamp(m.CurrentTradingPeriod.Regular.End, loc),
 		RegularGMTOffset: m.CurrentTradingPeriod.Regular.Gmtoffset,
 
 		PostTimezone:  m.CurrentTradingPeriod.Post.Timezone,
-		PostStart:     m.CurrentTradingPeriod.Post.Start,
-		PostEnd:       m.CurrentTradingPeriod.Post.End,
+		PostStart:     NewTimestamp(m.CurrentTradingPeriod.Post.Start, loc),
+		PostEnd:       NewTimestamp(m.CurrentTradingPeriod.Post.End, loc),
 		PostGMTOffset: m.CurrentTradingPeriod.Post.Gmtoffset,
 
 		Granularity: m.DataGranularity,
 		Ranges:      m.ValidRanges,
 	}
+	ClassifySession(&tick)
+	return tick
 }
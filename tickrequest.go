@@ -0,0 +1,259 @@
+package odbc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/piquette/finance-go/chart"
+	"github.com/piquette/finance-go/datetime"
+)
+
+// TickRequest asks for the bars of Symbol at Interval between Start
+// and End. Intraday bars outside the regular session are only included
+// with IncludePrePost.
+type TickRequest struct {
+	Symbol         string
+	Interval       datetime.Interval
+	Start          time.Time
+	End            time.Time
+	IncludePrePost bool
+}
+
+// Intervals are the bar intervals Yahoo serves charts at. The other
+// datetime.Interval values (6mo, 1y, ..., ytd, max) only describe query
+// ranges.
+var Intervals = []datetime.Interval{
+	datetime.OneMin,
+	datetime.TwoMins,
+	datetime.FiveMins,
+	datetime.FifteenMins,
+	datetime.ThirtyMins,
+	datetime.SixtyMins,
+	datetime.NinetyMins,
+	datetime.OneHour,
+	datetime.OneDay,
+	datetime.FiveDay,
+	datetime.OneMonth,
+	datetime.ThreeMonth,
+}
+
+// QueryRanges are the datetime.Interval values that only describe query
+// ranges. Older versions stored a copy of every bar under each of them.
+var QueryRanges = []datetime.Interval{
+	datetime.SixMonth,
+	datetime.OneYear,
+	datetime.TwoYear,
+	datetime.FiveYear,
+	datetime.TenYear,
+	datetime.YTD,
+	datetime.Max,
+}
+
+// IsBarInterval reports whether interval is one of Intervals.
+func IsBarInterval(interval datetime.Interval) bool {
+	for _, i := range Intervals {
+		if i == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxWindow returns the longest range Yahoo serves in a single chart
+// request for interval, or 0 if there is no limit.
+func MaxWindow(interval datetime.Interval) time.Duration {
+	day := 24 * time.Hour
+	switch interval {
+	case datetime.OneMin:
+		return 7 * day
+	case datetime.TwoMins, datetime.FiveMins, datetime.FifteenMins, datetime.ThirtyMins, datetime.NinetyMins:
+		return 60 * day
+	case datetime.SixtyMins, datetime.OneHour:
+		return 730 * day
+	}
+	return 0
+}
+
+// MaxHistory returns how far back from now Yahoo keeps bars of
+// interval, or 0 if it keeps them back to the first trade date.
+func MaxHistory(interval datetime.Interval) time.Duration {
+	day := 24 * time.Hour
+	switch interval {
+	case datetime.OneMin:
+		return 30 * day
+	case datetime.TwoMins, datetime.FiveMins, datetime.FifteenMins, datetime.ThirtyMins, datetime.NinetyMins:
+		return 60 * day
+	case datetime.SixtyMins, datetime.OneHour:
+		return 730 * day
+	}
+	return 0
+}
+
+// available limits r to the history Yahoo keeps at now; ok is false if
+// none of r is left.
+func (r TickRequest) available(now time.Time) (TickRequest, bool) {
+	h := MaxHistory(r.Interval)
+	if h == 0 {
+		return r, true
+	}
+	oldest := now.Add(-h)
+	if r.Start.Before(oldest) {
+		r.Start = oldest
+	}
+	return r, r.End.After(oldest)
+}
+
+// Windows splits r into consecutive requests that each fit into
+// MaxWindow(r.Interval).
+func (r TickRequest) Windows() []TickRequest {
+	size := MaxWindow(r.Interval)
+	if size == 0 || !r.End.After(r.Start) {
+		return []TickRequest{r}
+	}
+
+	ws := []TickRequest{}
+	for start := r.Start; start.Before(r.End); start = start.Add(size) {
+		w := r
+		w.Start = start
+		w.End = start.Add(size)
+		if w.End.After(r.End) {
+			w.End = r.End
+		}
+		ws = append(ws, w)
+	}
+	return ws
+}
+
+func (r TickRequest) Params() *chart.Params {
+	start, end := r.Start, r.End
+	return &chart.Params{
+		Symbol:     r.Symbol,
+		Start:      datetime.New(&start),
+		End:        datetime.New(&end),
+		Interval:   r.Interval,
+		IncludeExt: r.IncludePrePost,
+	}
+}
+
+func fetchWindow(ctx context.Context, p Provider, w TickRequest, seen map[int64]bool) ([]Tick, error) {
+	ticks := []Tick{}
+	iter := p.GetChart(ctx, w.Params())
+	for iter.Next() {
+		tick := NewTickFromAPI(&MetaTick{
+			ChartBar:  *iter.Bar(),
+			ChartMeta: iter.Meta(),
+		})
+		if seen[tick.Timestamp.Unix()] {
+			continue
+		}
+
+		seen[tick.Timestamp.Unix()] = true
+		ticks = append(ticks, tick)
+	}
+	return ticks, iter.Err()
+}
+
+// FetchTicks downloads all bars of r, one chart request per window.
+// Bars older than Yahoo keeps are not asked for, so -from max does not
+// turn into thousands of empty intraday requests.
+func FetchTicks(ctx context.Context, p Provider, r TickRequest) ([]Tick, error) {
+	if !IsBarInterval(r.Interval) {
+		return nil, fmt.Errorf("%s is a query range, not a bar interval", r.Interval)
+	}
+
+	ticks := []Tick{}
+	r, ok := r.available(time.Now())
+	if !ok {
+		return ticks, nil
+	}
+	seen := map[int64]bool{}
+	for _, w := range r.Windows() {
+		ts, err := fetchWindow(ctx, p, w, seen)
+		ticks = append(ticks, ts...)
+		if err != nil {
+			return ticks, err
+		}
+	}
+	return ticks, nil
+}
+
+// BackfillTicks downloads the bars of r window by window, starting at
+// r.End and walking back towards r.Start. Yahoo keeps only limited
+// intraday history, so it stops at the first window without bars, and
+// a failing window after the first one is taken as the end of the
+// available history as well.
+func BackfillTicks(ctx context.Context, p Provider, r TickRequest) ([]Tick, error) {
+	if !IsBarInterval(r.Interval) {
+		return nil, fmt.Errorf("%s is a query range, not a bar interval", r.Interval)
+	}
+
+	ticks := []Tick{}
+	r, ok := r.available(time.Now())
+	if !ok {
+		return ticks, nil
+	}
+	seen := map[int64]bool{}
+	ws := r.Windows()
+	for i := len(ws) - 1; i >= 0; i-- {
+		ts, err := fetchWindow(ctx, p, ws[i], seen)
+		ticks = append(ticks, ts...)
+		if err != nil && i == len(ws)-1 {
+			return ticks, err
+		}
+		if err != nil || len(ts) == 0 {
+			break
+		}
+	}
+	return ticks, nil
+}
+
+var (
+	relativeUnits = []struct {
+		suffix string
+		add    func(t time.Time, n int) time.Time
+	}{
+		{"mo", func(t time.Time, n int) time.Time { return t.AddDate(0, -n, 0) }},
+		{"y", func(t time.Time, n int) time.Time { return t.AddDate(-n, 0, 0) }},
+		{"w", func(t time.Time, n int) time.Time { return t.AddDate(0, 0, -7*n) }},
+		{"d", func(t time.Time, n int) time.Time { return t.AddDate(0, 0, -n) }},
+		{"h", func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Hour) }},
+		{"m", func(t time.Time, n int) time.Time { return t.Add(-time.Duration(n) * time.Minute) }},
+	}
+)
+
+// ParseTimeBound parses an absolute date (2006-01-02 or RFC 3339),
+// "now", the ranges "ytd" and "max", or a duration relative to now such
+// as 90m, 12h, 30d, 2w, 6mo or 5y.
+func ParseTimeBound(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "", "now":
+		return now, nil
+	case string(datetime.YTD):
+		return time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location()), nil
+	case string(datetime.Max):
+		return time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+
+	for _, u := range relativeUnits {
+		if !strings.HasSuffix(s, u.suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(s, u.suffix))
+		if err != nil || n < 0 {
+			break
+		}
+		return u.add(now, n), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a date or relative duration", s)
+}
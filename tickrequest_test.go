@@ -0,0 +1,111 @@
+package odbc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/piquette/finance-go/chart"
+	"github.com/piquette/finance-go/datetime"
+)
+
+func TestTickRequestWindows(t *testing.T) {
+	end := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -20)
+
+	ws := TickRequest{Symbol: "AAPL", Interval: datetime.OneMin, Start: start, End: end}.Windows()
+	if len(ws) != 3 {
+		t.Fatalf("got %d windows, want 3", len(ws))
+	}
+	if !ws[0].Start.Equal(start) || !ws[2].End.Equal(end) || !ws[1].Start.Equal(ws[0].End) {
+		t.Errorf("windows do not cover the range: %v", ws)
+	}
+
+	if ws := (TickRequest{Interval: datetime.OneDay, Start: start, End: end}).Windows(); len(ws) != 1 {
+		t.Errorf("daily bars were chunked into %d windows", len(ws))
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC)
+	for in, want := range map[string]time.Time{
+		"now":        now,
+		"2020-03-16": time.Date(2020, 3, 16, 0, 0, 0, 0, time.UTC),
+		"5y":         time.Date(2018, 12, 1, 12, 0, 0, 0, time.UTC),
+		"6mo":        time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		"30m":        time.Date(2023, 12, 1, 11, 30, 0, 0, time.UTC),
+		"ytd":        time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		got, err := ParseTimeBound(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTimeBound(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+
+	if _, err := ParseTimeBound("yesterday", now); err == nil {
+		t.Error("expected an error for an unknown bound")
+	}
+}
+
+func TestFetchTicksRejectsQueryRanges(t *testing.T) {
+	ctx := context.Background()
+	end := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	for _, r := range QueryRanges {
+		if IsBarInterval(r) {
+			t.Errorf("%s is taken as a bar interval", r)
+		}
+		req := TickRequest{Symbol: "FIX", Interval: r, Start: end.AddDate(-1, 0, 0), End: end}
+		if ticks, err := FetchTicks(ctx, fixtureProvider{}, req); err == nil || len(ticks) > 0 {
+			t.Errorf("fetched %d bars at the query range %s", len(ticks), r)
+		}
+		if ticks, err := BackfillTicks(ctx, fixtureProvider{}, req); err == nil || len(ticks) > 0 {
+			t.Errorf("backfilled %d bars at the query range %s", len(ticks), r)
+		}
+	}
+	for _, i := range Intervals {
+		if !IsBarInterval(i) {
+			t.Errorf("%s is not taken as a bar interval", i)
+		}
+	}
+}
+
+// chartCounter counts the chart requests made of fixtureProvider.
+type chartCounter struct {
+	fixtureProvider
+	requests int
+}
+
+func (p *chartCounter) GetChart(ctx context.Context, params *chart.Params) ChartIterator {
+	p.requests++
+	return p.fixtureProvider.GetChart(ctx, params)
+}
+
+func TestFetchTicksHistory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	start, err := ParseTimeBound("max", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		interval   datetime.Interval
+		start, end time.Time
+		requests   int
+	}{
+		// 30 days of 1m bars in windows of 7 days
+		{datetime.OneMin, start, now, 5},
+		{datetime.FiveMins, start, now, 1},
+		{datetime.OneMin, now.AddDate(0, 0, -3), now, 1},
+		{datetime.OneMin, start, now.AddDate(0, -2, 0), 0},
+	} {
+		p := &chartCounter{}
+		req := TickRequest{Symbol: "FIX", Interval: c.interval, Start: c.start, End: c.end}
+		if _, err := FetchTicks(ctx, p, req); err != nil {
+			t.Fatal(err)
+		}
+		if p.requests != c.requests {
+			t.Errorf("fetching %s bars from %s made %d chart requests, want %d", c.interval, c.start.Format("2006-01-02"), p.requests, c.requests)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package odbc
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Timestamp is a point in time stored in a native timestamp column. It
+// keeps the location it was created in, usually the exchange timezone,
+// and still reads the Unix seconds older versions stored in integer
+// columns and JSON files. The zero Timestamp is stored as NULL.
+type Timestamp struct {
+	time.Time
+}
+
+var timestampType = reflect.TypeOf(Timestamp{})
+
+// NewTimestamp converts Unix seconds reported by the API into a
+// Timestamp in loc. 0 is taken as "not reported".
+func NewTimestamp(unix int, loc *time.Location) Timestamp {
+	if unix == 0 {
+		return Timestamp{}
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return Timestamp{time.Unix(int64(unix), 0).In(loc)}
+}
+
+// In returns t in loc.
+func (t Timestamp) In(loc *time.Location) Timestamp {
+	if t.IsZero() || loc == nil {
+		return t
+	}
+	return Timestamp{t.Time.In(loc)}
+}
+
+func (t Timestamp) Value() (driver.Value, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	return t.UTC(), nil
+}
+
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	time.RFC3339Nano,
+}
+
+func parseTimestamp(s string) (Timestamp, error) {
+	if s == "" {
+		return Timestamp{}, nil
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return NewTimestamp(int(unix), time.UTC), nil
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Timestamp{t}, nil
+		}
+	}
+	return Timestamp{}, fmt.Errorf("cannot parse %q as a timestamp", s)
+}
+
+func (t *Timestamp) Scan(v interface{}) (err error) {
+	switch v := v.(type) {
+	case nil:
+		*t = Timestamp{}
+	case time.Time:
+		*t = Timestamp{v}
+	case int64:
+		*t = NewTimestamp(int(v), time.UTC)
+	case float64:
+		*t = NewTimestamp(int(v), time.UTC)
+	case []byte:
+		*t, err = parseTimestamp(string(v))
+	case string:
+		*t, err = parseTimestamp(v)
+	default:
+		err = fmt.Errorf("cannot scan %T into a timestamp", v)
+	}
+	return
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return t.Time.MarshalJSON()
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*t = Timestamp{}
+		return nil
+	}
+	if len(data) > 0 && data[0] != '"' {
+		unix, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return err
+		}
+		*t = NewTimestamp(int(unix), time.UTC)
+		return nil
+	}
+	return t.Time.UnmarshalJSON(data)
+}
+
+var locations sync.Map
+
+// exchangeLocation loads the timezone name as reported by Yahoo,
+// falling back to a fixed offset if it is unknown.
+func exchangeLocation(name string, gmtOffset int) *time.Location {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" {
+		return time.FixedZone(name, gmtOffset)
+	}
+	locations.Store(name, loc)
+	return loc
+}
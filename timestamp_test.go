@@ -0,0 +1,42 @@
+package odbc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestampScan(t *testing.T) {
+	want := time.Date(2023, time.November, 27, 14, 30, 0, 0, time.UTC)
+	for _, v := range []interface{}{want.Unix(), "1701095400", []byte("2023-11-27 14:30:00+00:00"), want.In(time.Local)} {
+		var ts Timestamp
+		if err := ts.Scan(v); err != nil || !ts.Equal(want) {
+			t.Errorf("Scan(%v) = %v, %v", v, ts, err)
+		}
+	}
+
+	var ts Timestamp
+	if err := ts.Scan(nil); err != nil || !ts.IsZero() {
+		t.Errorf("Scan(nil) = %v, %v", ts, err)
+	}
+	if v, _ := (Timestamp{}).Value(); v != nil {
+		t.Errorf("zero timestamp stored as %v, want NULL", v)
+	}
+}
+
+func TestTimestampJSON(t *testing.T) {
+	var a CorporateAction
+	if err := json.Unmarshal([]byte(`{"timestamp":1701095400}`), &a); err != nil || a.Timestamp.Unix() != 1701095400 {
+		t.Errorf("legacy unix timestamp read as %v, %v", a.Timestamp, err)
+	}
+
+	ny, _ := time.LoadLocation("America/New_York")
+	ts := NewTimestamp(1701095400, ny)
+	b, err := json.Marshal(ts)
+	if err != nil || string(b) != `"2023-11-27T09:30:00-05:00"` {
+		t.Errorf("Marshal = %s, %v", b, err)
+	}
+	if b, _ := json.Marshal(Timestamp{}); string(b) != "null" {
+		t.Errorf("zero timestamp marshalled as %s", b)
+	}
+}
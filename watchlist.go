@@ -0,0 +1,190 @@
+package odbc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/piquette/finance-go/datetime"
+)
+
+const WatchlistTableName = "watchlists"
+
+// WatchlistMember is a symbol of the watchlist named Watchlist. Class
+// optionally names the asset class of the symbol, Intervals the comma
+// separated bar intervals to download it at instead of the default
+// ones.
+type WatchlistMember struct {
+	DBEntry
+
+	Watchlist string `db:"watchlist" json:"watchlist"`
+	Symbol    string `db:"symbol" json:"symbol"`
+	Class     string `db:"class" json:"class"`
+	Intervals string `db:"intervals" json:"intervals"`
+}
+
+// WatchlistKeyColumns is the natural key of the watchlist table.
+var WatchlistKeyColumns = []string{"watchlist", "symbol"}
+
+type watchlistKey struct {
+	Watchlist string
+	Symbol    string
+}
+
+func (m *WatchlistMember) key() watchlistKey {
+	return watchlistKey{m.Watchlist, m.Symbol}
+}
+
+// IntervalList returns the intervals of m, if any.
+func (m *WatchlistMember) IntervalList() []string {
+	if m.Intervals == "" {
+		return nil
+	}
+	return strings.Split(m.Intervals, ",")
+}
+
+// WatchlistStore is implemented by sinks that persist watchlists.
+// AddToWatchlist replaces the class and intervals of members that are
+// stored already.
+type WatchlistStore interface {
+	WatchlistNames(ctx context.Context) ([]string, error)
+	ReadWatchlist(ctx context.Context, name string) ([]WatchlistMember, error)
+	AddToWatchlist(ctx context.Context, ms []WatchlistMember) error
+	RemoveFromWatchlist(ctx context.Context, name string, symbols []string) error
+}
+
+// NewWatchlistMember checks class and intervals, which may be empty,
+// and returns the member symbol of watchlist name.
+func NewWatchlistMember(name, symbol, class string, intervals []string) (m WatchlistMember, err error) {
+	m = WatchlistMember{Watchlist: name, Symbol: strings.TrimSpace(symbol)}
+	if m.Symbol == "" {
+		err = fmt.Errorf("empty symbol")
+		return
+	}
+
+	if class = strings.ToLower(strings.TrimSpace(class)); class != "" {
+		c, ok := LookupAssetClass(class)
+		if !ok {
+			err = fmt.Errorf("%s: unknown asset class %q", m.Symbol, class)
+			return
+		}
+		m.Class = c.Name
+	}
+
+	valid := []string{}
+	for _, i := range intervals {
+		if i = strings.TrimSpace(i); i == "" {
+			continue
+		}
+		if !IsBarInterval(datetime.Interval(i)) {
+			err = fmt.Errorf("%s: unknown interval %q", m.Symbol, i)
+			return
+		}
+		valid = append(valid, i)
+	}
+	m.Intervals = strings.Join(valid, ",")
+	return
+}
+
+func splitIntervals(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' '
+	})
+}
+
+// ParseWatchlist reads the members of watchlist name from r. Plain text
+// lists whitespace or comma separated symbols, '#' starting a comment.
+// CSV, chosen by csv, starts with a header naming the columns symbol,
+// class and intervals, of which only symbol is required; the intervals
+// of a symbol are separated by semicolons or spaces.
+func ParseWatchlist(name string, r io.Reader, csv bool) ([]WatchlistMember, error) {
+	if csv {
+		return parseWatchlistCSV(name, r)
+	}
+
+	ms := []WatchlistMember{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		for _, symbol := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			m, err := NewWatchlistMember(name, symbol, "", nil)
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, m)
+		}
+	}
+	return ms, scanner.Err()
+}
+
+func parseWatchlistCSV(name string, r io.Reader) ([]WatchlistMember, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return []WatchlistMember{}, nil
+	}
+
+	column := map[string]int{"symbol": -1, "class": -1, "intervals": -1}
+	for i, h := range records[0] {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if _, ok := column[h]; !ok {
+			return nil, fmt.Errorf("unknown column %q, use symbol, class and intervals", h)
+		}
+		column[h] = i
+	}
+	if column["symbol"] < 0 {
+		return nil, fmt.Errorf("no symbol column")
+	}
+	field := func(record []string, name string) string {
+		if i := column[name]; i >= 0 && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	ms := []WatchlistMember{}
+	for n, record := range records[1:] {
+		m, err := NewWatchlistMember(name, field(record, "symbol"), field(record, "class"), splitIntervals(field(record, "intervals")))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n+2, err)
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// LoadWatchlistFile reads the watchlist at path, named after the file
+// without its extension. Files ending in .csv are read as CSV.
+func LoadWatchlistFile(path string) (name string, ms []WatchlistMember, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	ext := filepath.Ext(path)
+	name = strings.TrimSuffix(filepath.Base(path), ext)
+	ms, err = ParseWatchlist(name, bytes.NewReader(data), strings.EqualFold(ext, ".csv"))
+	if err != nil {
+		err = fmt.Errorf("%s: %s", path, err)
+	}
+	return
+}
+
+// IsWatchlistFile reports whether path names an existing file rather
+// than a watchlist.
+func IsWatchlistFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular()
+}
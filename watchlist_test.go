@@ -0,0 +1,99 @@
+package odbc
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseWatchlist(t *testing.T) {
+	ms, err := ParseWatchlist("dax", strings.NewReader("SAP.DE, SIE.DE # largest\n\n# ALV.DE\nBAS.DE\tBMW.DE\n"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbols := []string{}
+	for _, m := range ms {
+		if m.Watchlist != "dax" || m.Class != "" || m.Intervals != "" {
+			t.Errorf("unexpected member %+v", m)
+		}
+		symbols = append(symbols, m.Symbol)
+	}
+	if got := strings.Join(symbols, ","); got != "SAP.DE,SIE.DE,BAS.DE,BMW.DE" {
+		t.Errorf("got symbols %s", got)
+	}
+
+	ms, err = ParseWatchlist("mixed", strings.NewReader("Symbol,Class,Intervals\nAAPL,Equity,1d;1h\n^GDAXI,index,\nEURUSD=X,,\n"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 3 {
+		t.Fatalf("got %d members, want 3", len(ms))
+	}
+	if m := ms[0]; m.Symbol != "AAPL" || m.Class != "equity" || strings.Join(m.IntervalList(), ",") != "1d,1h" {
+		t.Errorf("unexpected member %+v", m)
+	}
+	if m := ms[1]; m.Class != "index" || m.IntervalList() != nil {
+		t.Errorf("unexpected member %+v", m)
+	}
+
+	for _, in := range []string{
+		"ticker\nAAPL\n",
+		"symbol,class\nAAPL,bond\n",
+		"symbol,intervals\nAAPL,1y\n",
+	} {
+		if _, err := ParseWatchlist("bad", strings.NewReader(in), true); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestFileSinkWatchlists(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := []WatchlistMember{}
+	for _, symbol := range []string{"SAP.DE", "SIE.DE", "BAS.DE"} {
+		m, err := NewWatchlistMember("dax", symbol, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ms = append(ms, m)
+	}
+	if err := s.AddToWatchlist(ctx, ms); err != nil {
+		t.Fatal(err)
+	}
+	ms[0].Intervals = "1d"
+	if err := s.AddToWatchlist(ctx, ms[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveFromWatchlist(ctx, "dax", []string{"SIE.DE"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewFileSink(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	names, err := s.WatchlistNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "dax" {
+		t.Fatalf("got watchlists %v", names)
+	}
+	got, err := s.ReadWatchlist(ctx, "dax")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Symbol != "BAS.DE" || got[1].Symbol != "SAP.DE" || got[1].Intervals != "1d" {
+		t.Errorf("got members %+v", got)
+	}
+}
@@ -0,0 +1,176 @@
+package odbc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/piquette/finance-go"
+	"github.com/piquette/finance-go/chart"
+	"github.com/piquette/finance-go/crypto"
+	"github.com/piquette/finance-go/equity"
+	"github.com/piquette/finance-go/etf"
+	"github.com/piquette/finance-go/forex"
+	"github.com/piquette/finance-go/form"
+	"github.com/piquette/finance-go/future"
+	"github.com/piquette/finance-go/index"
+	"github.com/piquette/finance-go/mutualfund"
+	"github.com/piquette/finance-go/option"
+	"github.com/piquette/finance-go/quote"
+	"github.com/shopspring/decimal"
+)
+
+// YahooProvider fetches market data from Yahoo Finance through
+// piquette/finance-go.
+type YahooProvider struct{}
+
+func (YahooProvider) GetQuote(ctx context.Context, symbol string) (*finance.Quote, error) {
+	i := quote.ListP(&quote.Params{
+		Params:  finance.Params{Context: &ctx},
+		Symbols: []string{symbol},
+	})
+
+	if !i.Next() {
+		return nil, notFound(i.Err(), "quote", symbol)
+	}
+	return i.Quote(), nil
+}
+
+// notFound is the error of a listing that did not yield symbol: the
+// error of its iterator, if any, as an empty listing means Yahoo does
+// not know the symbol.
+func notFound(err error, kind, symbol string) error {
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("Can't find %s for symbol: %s", kind, symbol)
+}
+
+func (YahooProvider) GetAsset(ctx context.Context, quoteType finance.QuoteType, symbol string) (interface{}, error) {
+	params := finance.Params{Context: &ctx}
+	symbols := []string{symbol}
+	kind := strings.ToLower(string(quoteType))
+
+	switch quoteType {
+	case finance.QuoteTypeCryptoPair:
+		i := crypto.ListP(&crypto.Params{Params: params, Symbols: symbols})
+		if !i.Next() {
+			return nil, notFound(i.Err(), kind, symbol)
+		}
+		return i.CryptoPair(), nil
+	case finance.QuoteTypeEquity:
+		i := equity.ListP(&equity.Params{Params: params, Symbols: symbols})
+		if !i.Next() {
+			return nil, notFound(i.Err(), kind, symbol)
+		}
+		return i.Equity(), nil
+	case finance.QuoteTypeETF:
+		i := etf.ListP(&etf.Params{Params: params, Symbols: symbols})
+		if !i.Next() {
+			return nil, notFound(i.Err(), kind, symbol)
+		}
+		return i.ETF(), nil
+	case finance.QuoteTypeForexPair:
+		i := forex.ListP(&forex.Params{Params: params, Symbols: symbols})
+		if !i.Next() {
+			return nil, notFound(i.Err(), kind, symbol)
+		}
+		return i.ForexPair(), nil
+	case finance.QuoteTypeFuture:
+		i := future.ListP(&future.Params{Params: params, Symbols: symbols})
+		if !i.Next() {
+			return nil, notFound(i.Err(), kind, symbol)
+		}
+		return i.Future(), nil
+	case finance.QuoteTypeIndex:
+		i := index.ListP(&index.Params{Params: params, Symbols: symbols})
+		if !i.Next() {
+			return nil, notFound(i.Err(), kind, symbol)
+		}
+		return i.Index(), nil
+	case finance.QuoteTypeMutualFund:
+		i := mutualfund.ListP(&mutualfund.Params{Params: params, Symbols: symbols})
+		if !i.Next() {
+			return nil, notFound(i.Err(), kind, symbol)
+		}
+		return i.MutualFund(), nil
+	case finance.QuoteTypeOption:
+		i := option.ListP(&option.Params{Params: params, Symbols: symbols})
+		if !i.Next() {
+			return nil, notFound(i.Err(), kind, symbol)
+		}
+		return i.Option(), nil
+	}
+	return nil, fmt.Errorf("yahoo: unsupported quote type %s", quoteType)
+}
+
+func (YahooProvider) GetChart(ctx context.Context, params *chart.Params) ChartIterator {
+	params.Context = &ctx
+	return chart.Get(params)
+}
+
+type yahooEvents struct {
+	Chart struct {
+		Result []struct {
+			Events struct {
+				Dividends map[string]struct {
+					Amount decimal.Decimal `json:"amount"`
+					Date   int             `json:"date"`
+				} `json:"dividends"`
+				Splits map[string]struct {
+					Date        int             `json:"date"`
+					Numerator   decimal.Decimal `json:"numerator"`
+					Denominator decimal.Decimal `json:"denominator"`
+				} `json:"splits"`
+			} `json:"events"`
+		} `json:"result"`
+		Error *finance.YfinError `json:"error"`
+	} `json:"chart"`
+}
+
+// GetCorporateActions requests the chart endpoint with dividend and
+// split events, which finance-go does not parse.
+func (YahooProvider) GetCorporateActions(ctx context.Context, symbol string, start, end time.Time) ([]CorporateAction, error) {
+	body := &form.Values{}
+	body.Set("period1", strconv.FormatInt(start.Unix(), 10))
+	body.Set("period2", strconv.FormatInt(end.Unix(), 10))
+	body.Set("interval", "1d")
+	body.Set("events", "div|split")
+
+	resp := yahooEvents{}
+	if err := finance.GetBackend(finance.YFinBackend).Call("v8/finance/chart/"+symbol, body, &ctx, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Chart.Error != nil {
+		return nil, resp.Chart.Error
+	}
+
+	actions := []CorporateAction{}
+	now := time.Now().UTC()
+	for _, r := range resp.Chart.Result {
+		for _, d := range r.Events.Dividends {
+			actions = append(actions, CorporateAction{
+				DBEntry:   DBEntry{InsertedAt: now},
+				Symbol:    symbol,
+				Type:      ActionDividend,
+				Timestamp: NewTimestamp(d.Date, time.UTC),
+				Amount:    d.Amount,
+			})
+		}
+		for _, s := range r.Events.Splits {
+			actions = append(actions, CorporateAction{
+				DBEntry:     DBEntry{InsertedAt: now},
+				Symbol:      symbol,
+				Type:        ActionSplit,
+				Timestamp:   NewTimestamp(s.Date, time.UTC),
+				Numerator:   s.Numerator,
+				Denominator: s.Denominator,
+			})
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Timestamp.Before(actions[j].Timestamp.Time) })
+	return actions, nil
+}